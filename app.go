@@ -2,62 +2,86 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
-
+	"log/slog"
+	"net"
 	"os"
 	"path/filepath"
 	"quick-clip/internal"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/tailscale/win"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
 	"golang.design/x/hotkey" // 注意：这个库通常要求在主线程初始化
 )
 
 // App struct
 type App struct {
 	ctx           context.Context
-	content       []any
-	keys          string
+	vaults        *internal.VaultService // 持有解锁后的密码库和密钥，自动锁定在后台 goroutine 中进行
 	action        *internal.Action
 	isVisible     bool
 	lastHwnd      win.HWND
 	configManager *internal.ConfigManager
-	config        *internal.Config
+	configMu      sync.RWMutex     // 保护 config 指针。config 只会被整体替换，不原地修改
+	config        *internal.Config // 后台 goroutine 通过 configSnapshot 读取
 	dataPath      string
+	backups       *internal.BackupManager
+	journal       *internal.Journal // 撤销/重做历史，隐藏窗口不影响它，锁定时清空，只在 vaults 的锁内访问
+	audit         *internal.AuditLog
+	agent         *internal.SSHAgent
+	log           *internal.Logger     // 写日志前按当前密码库脱敏，锁定时清空
+	agentMu       sync.Mutex           // 保护 agentListener、agentPath 和 pendingSSH
+	agentListener net.Listener         // ssh-agent 未启用时为 nil
+	agentPath     string               // agentListener 监听的路径
+	pendingSSH    map[string]chan bool // 等待用户确认的签名请求
 }
 
 // NewApp creates a new App application struct
-func NewApp(action *internal.Action, configManager *internal.ConfigManager, config *internal.Config) *App {
+func NewApp(action *internal.Action, configManager *internal.ConfigManager, config *internal.Config, log *internal.Logger) *App {
 	configDir, _ := os.UserConfigDir()
 	appConfigDir := filepath.Join(configDir, "quick-clip", "data") // 替换为你的应用名
 	os.MkdirAll(appConfigDir, 0755)
 	dataPath := filepath.Join(appConfigDir, "resource.json")
 
-	return &App{
-		keys:          "11112222111122221111222211112222",
+	a := &App{
 		action:        action,
 		isVisible:     false,
 		configManager: configManager,
 		config:        config,
 		dataPath:      dataPath,
+		journal:       internal.NewJournal(config.History.Limit),
+		audit:         internal.NewAuditLog(dataPath),
+		pendingSSH:    make(map[string]chan bool),
+		log:           log,
 	}
+	a.agent = internal.NewSSHAgent(a.confirmSSHKey)
+	a.vaults = internal.NewVaultService()
+	a.vaults.Subscribe(a.onVaultEvent)
+	a.backups = internal.NewBackupManager(filepath.Join(configDir, "quick-clip", "backups"), func() internal.BackupConfig {
+		return a.configSnapshot().Backup
+	})
+	return a
 }
 
 // startup is called when the app starts. The context is saved
 // so we can call the runtime methods
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
-	resource := internal.ReadContent(a.dataPath, a.keys)
-	if resource == nil {
-		// 如果读取失败（可能是解密失败），初始化为空数组，防止程序崩溃
-		a.content = make([]any, 0)
-	} else {
-		a.content = resource
-	}
+	// 密码库内容在前端调用 Unlock 之后才会解密读取
 
 	// 根据config初始化注册相关配置
-	a.RegisterGlobalHotkey(a.config.Shortcuts.WakeUp[0], a.config.Shortcuts.WakeUp[1])
-	a.action.SetTransparency(uint8(a.config.Appearance.Opacity))
+	cfg := a.configSnapshot()
+	a.RegisterGlobalHotkey(cfg.Shortcuts.WakeUp[0], cfg.Shortcuts.WakeUp[1])
+	a.action.SetTransparency(uint8(cfg.Appearance.Opacity))
+	go a.watchIdle()
+	go a.watchExpiry()
+	if err := a.applySSHAgent(); err != nil {
+		slog.Error("启动 ssh-agent 失败", "err", err)
+	}
 
 	// 注册窗口句柄
 	go func() {
@@ -82,17 +106,1126 @@ func (a *App) startup(ctx context.Context) {
 
 // shutdown is called when the app is about to close
 func (a *App) shutdown(ctx context.Context) {
-	internal.SaveContent(a.dataPath, a.keys, a.content)
+	a.stopSSHAgent()
+	a.removeAttachmentTemp()
+	err := a.vaults.Do(func(tx *internal.VaultTx) error {
+		return internal.SaveContent(a.dataPath, tx.Keys, tx.Vault)
+	})
+	if err != nil && !errors.Is(err, internal.ErrVaultLocked) {
+		slog.Error("退出时保存失败", "err", err)
+	}
+}
+
+// IsVaultCreated 密码库文件是否已存在，前端据此显示“设置主密码”或“解锁”
+func (a *App) IsVaultCreated() bool {
+	_, err := os.Stat(a.dataPath)
+	return err == nil
+}
+
+// IsUnlocked 是否已经用主密码解锁
+func (a *App) IsUnlocked() bool {
+	return a.vaults.Unlocked()
+}
+
+// VaultRequiresKeyFile 密码库是否需要密钥文件才能解锁
+func (a *App) VaultRequiresKeyFile() bool {
+	header, err := internal.ReadVaultHeader(a.dataPath)
+	return err == nil && header.KeyFile
+}
+
+// SelectKeyFile 打开文件选择框选择密钥文件，返回其路径
+func (a *App) SelectKeyFile() (string, error) {
+	return runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "选择密钥文件",
+	})
+}
+
+// GenerateKeyFile 生成一个新的随机密钥文件，返回其路径；用户取消时返回空字符串
+func (a *App) GenerateKeyFile() (string, error) {
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "保存密钥文件",
+		DefaultFilename: "quick-clip.key",
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+	if err := internal.GenerateKeyFile(path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Unlock 用主密码（及密钥文件）解锁密码库；首次使用时以该凭据创建新库，旧版本文件会被迁移
+func (a *App) Unlock(creds internal.Credentials) error {
+	if creds.Password == "" {
+		return internal.ErrEmptyPassword
+	}
+	keys := internal.NewCredentialsKeyProvider(creds)
+	if err := a.open(keys); err != nil {
+		return err
+	}
+	if a.configSnapshot().Security.RememberKey {
+		if err := a.rememberKey(keys); err != nil {
+			slog.Warn("保存密钥到密钥环失败", "err", err)
+		}
+	}
+	return nil
+}
+
+// UnlockWithKeyring 用之前保存在系统密钥环中的密钥解锁，需要开启 Security.RememberKey
+func (a *App) UnlockWithKeyring() error {
+	keys, err := internal.NewKeyringKeyProvider()
+	if err != nil {
+		return err
+	}
+	if err := a.open(keys); err != nil {
+		// 没有交给 vaults，由这里关闭密钥环会话
+		keys.ForgetKey()
+		return err
+	}
+	return nil
+}
+
+// KeyringUnlockAvailable 解锁界面是否显示“用密钥环解锁”
+func (a *App) KeyringUnlockAvailable() bool {
+	return internal.KeyringSupported() && a.configSnapshot().Security.RememberKey && a.IsVaultCreated()
+}
+
+// KeyringSupported 设置界面是否显示“记住密钥”，目前只有 Linux 支持系统密钥环
+func (a *App) KeyringSupported() bool {
+	return internal.KeyringSupported()
+}
+
+// open 用 keys 打开密码库并交给 vaults，文件损坏时隔离并进入安全模式
+func (a *App) open(keys internal.KeyProvider) error {
+	vault, err := internal.UnlockVault(a.dataPath, keys)
+	if internal.IsVaultCorrupt(err) {
+		// 文件已损坏：隔离原文件并进入安全模式，绝不用空内容覆盖它
+		if _, qErr := internal.QuarantineVault(a.dataPath, err); qErr != nil {
+			return qErr
+		}
+		runtime.EventsEmit(a.ctx, "vault-safe-mode")
+		return err
+	} else if err != nil {
+		return err
+	}
+	// 回收站中过期的节点在解锁时清理
+	if vault.PurgeTrash(a.configSnapshot().Trash.PurgeDays) > 0 {
+		vault.PruneBlobs()
+		if err := a.backups.Backup(a.dataPath); err != nil {
+			return fmt.Errorf("backup before save: %w", err)
+		}
+		if err := internal.SaveContent(a.dataPath, keys, vault); err != nil {
+			return err
+		}
+		// 保存的撤销历史中还有被永久删除的节点，一并删除
+		os.Remove(a.journalPath())
+	}
+	a.vaults.Open(vault, keys)
+	return nil
+}
+
+// rememberKey 把 keys 当前用于密码库的密钥保存到系统密钥环
+func (a *App) rememberKey(keys internal.KeyProvider) error {
+	header, err := internal.ReadVaultHeader(a.dataPath)
+	if err != nil {
+		return err
+	}
+	key, err := keys.ObtainKey(header)
+	if err != nil {
+		return err
+	}
+	return internal.RememberKeyInKeyring(key)
+}
+
+// GetSafeMode 返回安全模式信息（错误类型、原因、隔离文件路径），正常时返回 nil
+func (a *App) GetSafeMode() *internal.SafeModeInfo {
+	return internal.ReadSafeMode(a.dataPath)
+}
+
+// ResetVault 用户确认后退出安全模式，隔离文件保留，下次解锁时新建空的密码库
+func (a *App) ResetVault() error {
+	return internal.ResetSafeMode(a.dataPath)
+}
+
+// ChangeMasterPassword 修改主密码或密钥文件，用新的 salt 和密钥重新加密密码库及其所有备份
+func (a *App) ChangeMasterPassword(oldCreds internal.Credentials, newCreds internal.Credentials) error {
+	return a.vaults.Do(func(tx *internal.VaultTx) error {
+		oldKeys := internal.NewCredentialsKeyProvider(oldCreds)
+		newKeys := internal.NewCredentialsKeyProvider(newCreds)
+		oldHeader, err := internal.ReadVaultHeader(a.dataPath)
+		if err != nil {
+			return err
+		}
+		if err := internal.CheckKey(a.dataPath, oldKeys); err != nil {
+			return err
+		}
+		// 主库、备份和审计日志使用同一个新密钥：先派生并缓存，RekeyFile 会沿用它
+		newKey, err := newKeys.ObtainKey(nil)
+		if err != nil {
+			return err
+		}
+		if err := newKeys.CacheKey(newKey); err != nil {
+			return err
+		}
+		// 审计日志先用新密钥写好临时文件，主库替换之后才生效，中途崩溃也不会出现新旧密钥混用的日志
+		if err := a.audit.PrepareRekey(oldKeys, newKey); err != nil {
+			return fmt.Errorf("re-encrypt audit log: %w", err)
+		}
+		if err := internal.RekeyFile(a.dataPath, oldKeys, newKeys); err != nil {
+			// 主库没有替换，丢弃审计日志的临时文件
+			return errors.Join(err, a.audit.FinishRekey())
+		}
+		tx.Keys.ForgetKey()
+		tx.SetKeys(newKeys)
+		tx.Touch()
+		a.persistJournal(newKeys)
+
+		// 主库已经换成新密码，备份重新加密失败只影响对应的文件，错误照常返回给前端提示
+		err = errors.Join(a.audit.FinishRekey(), a.backups.Rekey(oldKeys, newKeys))
+		oldKeys.ForgetKey()
+		if a.configSnapshot().Security.RememberKey {
+			// 新 salt 对应新的密钥环条目，旧条目已经无用
+			err = errors.Join(err, internal.ForgetKeyInKeyring(oldHeader), a.rememberKey(newKeys))
+		}
+		return err
+	})
+}
+
+// ListBackups 按时间从新到旧列出密码库的备份
+func (a *App) ListBackups() ([]internal.BackupInfo, error) {
+	return a.backups.List()
+}
+
+// PreviewBackup 用当前密钥解密备份并返回其内容，不会修改密码库
+func (a *App) PreviewBackup(id string) ([]any, error) {
+	var content []any
+	err := a.vaults.Do(func(tx *internal.VaultTx) error {
+		tx.Touch()
+		vault, err := a.backups.Read(id, tx.Keys)
+		if err != nil {
+			return err
+		}
+		content = vault.ToLegacy()
+		return nil
+	})
+	return content, err
+}
+
+// RestoreBackup 用备份替换当前密码库，当前版本会先被备份，恢复本身也可以撤销。
+// 安全模式下无需解锁，恢复后退出安全模式，再用该备份的密码解锁即可
+func (a *App) RestoreBackup(id string) error {
+	if internal.ReadSafeMode(a.dataPath) != nil {
+		if err := a.backups.Restore(id, a.dataPath); err != nil {
+			return err
+		}
+		return internal.ResetSafeMode(a.dataPath)
+	}
+
+	return a.vaults.Do(func(tx *internal.VaultTx) error {
+		// 先确认备份能用当前密钥解密，避免恢复出一个打不开的密码库
+		vault, err := a.backups.Read(id, tx.Keys)
+		if err != nil {
+			return err
+		}
+		if err := a.backups.Restore(id, a.dataPath); err != nil {
+			return err
+		}
+		countersChanged := vault.KeepOTPCounters(tx.Vault)
+		if a.stampAudit(tx, vault) || countersChanged {
+			if err := internal.SaveContent(a.dataPath, tx.Keys, vault); err != nil {
+				return err
+			}
+		}
+		a.journal.Record("restore-backup", tx.Vault, vault)
+		a.persistJournal(tx.Keys)
+		tx.Touch()
+		tx.Replace("restore-backup", vault)
+		return nil
+	})
+}
+
+// onVaultEvent 把密码库的变化同步给撤销历史、审计日志、ssh-agent、日志脱敏和前端，在 vaults 的锁内调用
+func (a *App) onVaultEvent(e internal.VaultEvent) {
+	switch e.Kind {
+	case internal.VaultUnlocked:
+		history := a.configSnapshot().History
+		a.journal = internal.NewJournal(history.Limit)
+		if history.Persist {
+			journal, err := internal.LoadJournal(a.journalPath(), e.Keys, history.Limit)
+			if err != nil {
+				slog.Warn("读取撤销历史失败", "err", err)
+			} else {
+				a.journal = journal
+			}
+		}
+		a.audit.Reset()
+		a.agent.Load(e.After)
+		a.log.SetSecrets(e.After)
+	case internal.VaultChanged:
+		a.auditLog(e.Keys, internal.AuditChanges(e.Op, e.Before, e.After)...)
+		a.agent.Load(e.After)
+		a.log.SetSecrets(e.After)
+		if a.ctx != nil {
+			runtime.EventsEmit(a.ctx, "update-content", e.Op)
+		}
+	case internal.VaultLocked:
+		a.journal = internal.NewJournal(a.configSnapshot().History.Limit)
+		a.removeAttachmentTemp()
+		a.agent.Clear()
+		a.log.SetSecrets(nil)
+		a.denySSHRequests()
+		if a.ctx != nil {
+			runtime.EventsEmit(a.ctx, "vault-locked")
+		}
+	}
+}
+
+// Lock 丢弃内存中的密钥和明文内容，前端收到 vault-locked 后显示解锁界面
+func (a *App) Lock() {
+	a.vaults.Close()
+}
+
+// touch 记录一次用户操作，用于空闲自动锁定
+func (a *App) touch() {
+	a.vaults.Touch()
+}
+
+// watchIdle 定期检查空闲时间，超过 Security.AutoLockMinutes 后自动锁定
+func (a *App) watchIdle() {
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		minutes := a.configSnapshot().Security.AutoLockMinutes
+		if minutes <= 0 {
+			continue
+		}
+		if a.vaults.IdleFor() >= time.Duration(minutes)*time.Minute {
+			a.Lock()
+		}
+	}
+}
+
+// ExpiryReport 列出已过期和 Expiry.WarnDays 天内将要过期的条目
+func (a *App) ExpiryReport() ([]internal.ExpiryItem, error) {
+	vault, err := a.vaults.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	within := time.Duration(a.configSnapshot().Expiry.WarnDays) * 24 * time.Hour
+	return vault.ExpiryReport(time.Now(), within), nil
+}
+
+// GetSecurityReport 生成密码库健康报告：弱密码、重复使用、过旧和空值
+func (a *App) GetSecurityReport() (*internal.SecurityReport, error) {
+	vault, err := a.vaults.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	oldAfter := time.Duration(a.configSnapshot().Security.OldSecretDays) * 24 * time.Hour
+	return vault.SecurityReport(time.Now(), oldAfter), nil
+}
+
+// watchExpiry 每分钟检查一次过期条目，列表有变化时（包括解锁后第一次检查）发出 entries-expiring 事件，
+// 前端和托盘据此提醒。锁定时不检查
+func (a *App) watchExpiry() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	last := ""
+	for range ticker.C {
+		report, err := a.ExpiryReport()
+		if err != nil {
+			last = ""
+			continue
+		}
+		// 日期变化时也重新发送，托盘每天对仍然过期的条目再通知一次
+		key := time.Now().Format(time.DateOnly) + ";"
+		for _, item := range report {
+			key += fmt.Sprintf("%s:%t;", item.ID, item.Expired)
+		}
+		if key == last {
+			continue
+		}
+		last = key
+		runtime.EventsEmit(a.ctx, "entries-expiring", report)
+	}
+}
+
+// SetExpiry 设置条目的过期时间和轮换周期（天），expiresAt 为 nil 表示不过期或按轮换周期从现在起算
+func (a *App) SetExpiry(id string, expiresAt *time.Time, rotationDays int) error {
+	return a.mutate("expiry", func(v *internal.Vault) error {
+		return v.SetExpiry(id, expiresAt, rotationDays)
+	})
+}
+
+// GeneratePassword 按规则生成密码或短语
+func (a *App) GeneratePassword(policy internal.GeneratorPolicy) (string, error) {
+	return internal.Generate(policy)
+}
+
+// GeneratorPolicyFor 返回在 folderID（空为根目录）下新建条目时使用的生成规则
+func (a *App) GeneratorPolicyFor(folderID string) (internal.GeneratorPolicy, error) {
+	vault, err := a.vaults.Snapshot()
+	if err != nil {
+		return internal.GeneratorPolicy{}, err
+	}
+	return vault.GeneratorPolicyFor(folderID, a.configSnapshot().Generator)
+}
+
+// SetFolderGeneratorPolicy 设置文件夹的默认生成规则，policy 为 nil 时改为继承上级
+func (a *App) SetFolderGeneratorPolicy(folderID string, policy *internal.GeneratorPolicy) error {
+	return a.mutate("generator-policy", func(v *internal.Vault) error {
+		return v.SetGeneratorPolicy(folderID, policy)
+	})
+}
+
+// GetContent 以旧的 []any 结构返回解密后的内容，锁定状态下返回 ErrVaultLocked
+func (a *App) GetContent() ([]any, error) {
+	var content []any
+	err := a.vaults.Do(func(tx *internal.VaultTx) error {
+		tx.Touch()
+		content = tx.Vault.ToLegacy()
+		return nil
+	})
+	return content, err
+}
+
+// SaveContent 保存前端提交的 []any 内容，合并进 Vault 时保留原有节点的 ID，写入失败时返回错误供前端提示
+func (a *App) SaveContent(data []any) error {
+	return a.applyContent("save", data)
+}
+
+// applyContent 把 []any 内容合并进密码库，作为一次名为 op 的修改保存
+func (a *App) applyContent(op string, data []any) error {
+	return a.vaults.Do(func(tx *internal.VaultTx) error {
+		tx.Touch()
+		vault, err := tx.Vault.Clone()
+		if err != nil {
+			return err
+		}
+		if err := vault.ApplyLegacy(data); err != nil {
+			return err
+		}
+		// 导出文件中的 HOTP 计数器可能比当前的小，导入时不能让它回退
+		vault.KeepOTPCounters(tx.Vault)
+		return a.commit(tx, op, vault)
+	})
+}
+
+// ExportContent 把密码库以明文 JSON 导出到用户选择的文件并记入审计日志，返回保存的路径；用户取消时返回空字符串
+func (a *App) ExportContent() (string, error) {
+	content, err := a.GetContent()
+	if err != nil {
+		return "", err
+	}
+	path, err := a.action.ExportJson(content, a.ctx)
+	if err != nil || path == "" {
+		return "", err
+	}
+	a.vaults.Do(func(tx *internal.VaultTx) error {
+		a.auditLog(tx.Keys, internal.AuditRecord{Action: internal.AuditExport, Detail: path})
+		return nil
+	})
+	return path, nil
+}
+
+// ImportContent 从用户选择的明文 JSON 文件导入，保存成功后记入审计日志；用户取消时什么都不做
+func (a *App) ImportContent() error {
+	content, path := a.action.ImportJson(a.ctx)
+	if content == nil {
+		return nil
+	}
+	slog.Info("导入内容", "items", len(content), "source", path)
+	if err := a.applyContent("import", content); err != nil {
+		return err
+	}
+	a.vaults.Do(func(tx *internal.VaultTx) error {
+		a.auditLog(tx.Keys, internal.AuditRecord{Action: internal.AuditImport, Detail: path})
+		return nil
+	})
+	return nil
+}
+
+// GetVault 返回带 ID 的完整目录树，前端按节点 ID 调用下面的修改接口。
+// 附件内容、敏感字段、验证码种子和 SSH 私钥不发给前端，需要时用 RevealEntry 或复制接口取得
+func (a *App) GetVault() (*internal.Vault, error) {
+	var vault *internal.Vault
+	err := a.vaults.Do(func(tx *internal.VaultTx) error {
+		tx.Touch()
+		var err error
+		vault, err = tx.Vault.Redacted()
+		return err
+	})
+	return vault, err
+}
+
+// commit 保存 vault 并在 tx 中换入，同时记入撤销历史。
+// 写入前保留上一个版本，编辑或导入出错时可以从备份恢复
+func (a *App) commit(tx *internal.VaultTx, op string, vault *internal.Vault) error {
+	if err := a.backups.Backup(a.dataPath); err != nil {
+		return fmt.Errorf("backup before save: %w", err)
+	}
+	a.stampAudit(tx, vault)
+	if err := internal.SaveContent(a.dataPath, tx.Keys, vault); err != nil {
+		return err
+	}
+	if err := a.journal.Record(op, tx.Vault, vault); err != nil {
+		slog.Warn("记录撤销历史失败", "err", err)
+	}
+	a.persistJournal(tx.Keys)
+	tx.Replace(op, vault)
+	return nil
+}
+
+// stampAudit 保存前把审计日志当前的记录数写入 vault，返回 vault 是否因此改变。
+// 记录数只增不减，撤销或恢复备份也不会让它变小
+func (a *App) stampAudit(tx *internal.VaultTx, vault *internal.Vault) bool {
+	seq, err := a.audit.Seq(tx.Keys)
+	if err != nil {
+		slog.Warn("读取审计日志失败", "err", err)
+	}
+	seq = max(seq, tx.Vault.AuditSeq, vault.AuditSeq)
+	changed := seq != vault.AuditSeq
+	vault.AuditSeq = seq
+	return changed
+}
+
+// auditLog 追加审计日志，写入失败只打印，不影响操作本身
+func (a *App) auditLog(keys internal.KeyProvider, records ...internal.AuditRecord) {
+	for _, r := range records {
+		if err := a.audit.Append(keys, r); err != nil {
+			slog.Error("写入审计日志失败", "action", r.Action, "err", err)
+		}
+	}
+}
+
+// auditEntry 针对条目 id 的审计记录，条目名取 vault 中当前的名字
+func (a *App) auditEntry(vault *internal.Vault, action string, id string, detail string) internal.AuditRecord {
+	record := internal.AuditRecord{Action: action, EntryID: id, Detail: detail}
+	if node, _, _ := vault.Find(id); node != nil {
+		record.Name = node.NodeName()
+	}
+	return record
+}
+
+// ListAuditLog 按 filter 筛选审计日志，按时间从新到旧
+func (a *App) ListAuditLog(filter internal.AuditFilter) ([]internal.AuditRecord, error) {
+	var records []internal.AuditRecord
+	err := a.vaults.Do(func(tx *internal.VaultTx) error {
+		tx.Touch()
+		all, err := a.audit.Records(tx.Keys)
+		records = internal.FilterAudit(all, filter)
+		return err
+	})
+	return records, err
+}
+
+// VerifyAuditLog 校验审计日志的哈希链，发现记录被修改、删除或末尾被截断时 Valid 为 false
+func (a *App) VerifyAuditLog() (internal.AuditStatus, error) {
+	var status internal.AuditStatus
+	err := a.vaults.Do(func(tx *internal.VaultTx) error {
+		tx.Touch()
+		var err error
+		status, err = a.audit.Verify(tx.Keys, tx.Vault.AuditSeq)
+		return err
+	})
+	return status, err
+}
+
+func (a *App) journalPath() string {
+	return a.dataPath + ".journal"
+}
+
+// persistJournal 开启 History.Persist 时加密保存撤销历史，关闭时删除之前保存的文件，调用方需持有 vaults 的锁
+func (a *App) persistJournal(keys internal.KeyProvider) {
+	if !a.configSnapshot().History.Persist {
+		os.Remove(a.journalPath())
+		return
+	}
+	key, err := internal.CurrentKey(a.dataPath, keys)
+	if err == nil {
+		err = a.journal.Save(a.journalPath(), key)
+	}
+	if err != nil {
+		slog.Warn("保存撤销历史失败", "err", err)
+	}
+}
+
+// Undo 撤销最近一次修改，返回被撤销的操作名
+func (a *App) Undo() (string, error) {
+	return a.step(true)
+}
+
+// Redo 重做最近一次撤销的修改，返回重做的操作名
+func (a *App) Redo() (string, error) {
+	return a.step(false)
+}
+
+func (a *App) step(undo bool) (string, error) {
+	var op string
+	err := a.vaults.Do(func(tx *internal.VaultTx) error {
+		tx.Touch()
+		peek, done := a.journal.PeekRedo, a.journal.CommitRedo
+		if undo {
+			peek, done = a.journal.PeekUndo, a.journal.CommitUndo
+		}
+		vault, name, err := peek(tx.Vault)
+		if err != nil {
+			return err
+		}
+		vault.KeepOTPCounters(tx.Vault)
+		a.stampAudit(tx, vault)
+		if err := a.backups.Backup(a.dataPath); err != nil {
+			return fmt.Errorf("backup before save: %w", err)
+		}
+		if err := internal.SaveContent(a.dataPath, tx.Keys, vault); err != nil {
+			return err
+		}
+		done()
+		a.persistJournal(tx.Keys)
+		op = name
+		if undo {
+			tx.Replace("undo "+name, vault)
+		} else {
+			tx.Replace("redo "+name, vault)
+		}
+		return nil
+	})
+	return op, err
+}
+
+// mutate 在密码库的副本上执行 fn，备份并保存成功后才替换内存中的内容，
+// 任何一步失败时内存和文件都保持原样
+func (a *App) mutate(op string, fn func(v *internal.Vault) error) error {
+	return a.vaults.Do(func(tx *internal.VaultTx) error {
+		tx.Touch()
+		vault, err := tx.Vault.Clone()
+		if err != nil {
+			return err
+		}
+		if err := fn(vault); err != nil {
+			return err
+		}
+		vault.PurgeTrash(a.configSnapshot().Trash.PurgeDays)
+		vault.PruneBlobs()
+		return a.commit(tx, op, vault)
+	})
+}
+
+// CreateEntry 在 parentID（空字符串为根目录）下新建条目，返回新条目的 ID
+func (a *App) CreateEntry(parentID string, name string, fields []internal.Field) (string, error) {
+	var id string
+	err := a.mutate("create-entry", func(v *internal.Vault) error {
+		entry, err := v.CreateEntry(parentID, name, fields, -1)
+		if err == nil {
+			id = entry.ID
+		}
+		return err
+	})
+	return id, err
+}
+
+// CreateFolder 在 parentID（空字符串为根目录）下新建文件夹，返回新文件夹的 ID
+func (a *App) CreateFolder(parentID string, name string) (string, error) {
+	var id string
+	err := a.mutate("create-folder", func(v *internal.Vault) error {
+		folder, err := v.CreateFolder(parentID, name, -1)
+		if err == nil {
+			id = folder.ID
+		}
+		return err
+	})
+	return id, err
 }
 
-func (a *App) GetContent() []any {
-	return a.content
+// UpdateEntry 替换条目的字段，被修改的旧值连同 reason 保存在条目历史中
+func (a *App) UpdateEntry(id string, fields []internal.Field, reason string) error {
+	return a.mutate("update-entry", func(v *internal.Vault) error {
+		return v.UpdateEntry(id, fields, reason, a.configSnapshot().History.FieldVersions)
+	})
 }
 
-func (a *App) SaveContent(data []any) {
-	a.content = data
-	fmt.Println(a.content)
-	internal.SaveContent(a.dataPath, a.keys, a.content)
+// SetLabels 设置条目或文件夹的标签、收藏和颜色
+func (a *App) SetLabels(id string, labels internal.Labels) error {
+	return a.mutate("labels", func(v *internal.Vault) error {
+		return v.SetLabels(id, labels)
+	})
+}
+
+// Favourites 列出收藏的条目，锁定时为空
+func (a *App) Favourites() []*internal.Entry {
+	vault, err := a.vaults.Snapshot()
+	if err != nil {
+		return nil
+	}
+	return vault.Favourites()
+}
+
+// CopyEntry 把条目的主要值（密码或 value）写入剪贴板，有验证码的条目写入当前验证码
+func (a *App) CopyEntry(id string) error {
+	return a.copyField(id, "", internal.AuditCopy)
+}
+
+// copyField 把条目的 field 字段写入剪贴板并按 action 记入审计日志。
+// field 为空时与 CopyEntry 相同：有验证码的写入验证码，否则写入主要值
+func (a *App) copyField(id string, field string, action string) error {
+	return a.vaults.Do(func(tx *internal.VaultTx) error {
+		tx.Touch()
+		node, _, _ := tx.Vault.Find(id)
+		entry, ok := node.(*internal.Entry)
+		if !ok {
+			return internal.ErrNodeNotFound
+		}
+		var value string
+		switch {
+		case field != "":
+			f, ok := entry.Field(field)
+			if !ok {
+				return fmt.Errorf("%w: %q", internal.ErrFieldNotFound, field)
+			}
+			value = f.Value
+		case entry.OTP != nil:
+			code, err := a.nextOTP(tx, entry)
+			if err != nil {
+				return err
+			}
+			value, field = code, "otp"
+		default:
+			value = entry.PrimaryValue()
+		}
+		if err := runtime.ClipboardSetText(a.ctx, value); err != nil {
+			return err
+		}
+		a.auditLog(tx.Keys, a.auditEntry(tx.Vault, action, id, field))
+		return nil
+	})
+}
+
+// nextOTP 生成条目当前的验证码，HOTP 条目递增计数器并立即保存。
+// 计数器不是用户的修改，不记入撤销历史也不做备份，否则撤销后会重新生成用过的验证码
+func (a *App) nextOTP(tx *internal.VaultTx, entry *internal.Entry) (string, error) {
+	if entry.OTP.Type != internal.OTPTypeHOTP {
+		return entry.OTP.Code(time.Now())
+	}
+	vault, err := tx.Vault.Clone()
+	if err != nil {
+		return "", err
+	}
+	code, err := vault.NextOTP(entry.ID, time.Now())
+	if err != nil {
+		return "", err
+	}
+	a.stampAudit(tx, vault)
+	if err := internal.SaveContent(a.dataPath, tx.Keys, vault); err != nil {
+		return "", err
+	}
+	tx.Replace("hotp-counter", vault)
+	return code, nil
+}
+
+// SetOTP 设置条目的一次性验证码，otp 为 nil 时移除
+func (a *App) SetOTP(id string, otp *internal.OTP) error {
+	return a.mutate("otp", func(v *internal.Vault) error {
+		return v.SetOTP(id, otp)
+	})
+}
+
+// ParseOTPURI 解析 otpauth:// URI，供编辑框填入验证码设置
+func (a *App) ParseOTPURI(uri string) (*internal.OTP, error) {
+	return internal.ParseOTPURI(uri)
+}
+
+// ImportOTP 从每行一个的 otpauth:// 或 otpauth-migration:// URI 导入验证码，
+// 在 parentID（空字符串为根目录）下新建条目，返回导入的个数
+func (a *App) ImportOTP(parentID string, text string) (int, error) {
+	count := 0
+	err := a.mutate("import-otp", func(v *internal.Vault) error {
+		entries, err := v.ImportOTP(parentID, text)
+		count = len(entries)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	a.vaults.Do(func(tx *internal.VaultTx) error {
+		a.auditLog(tx.Keys, internal.AuditRecord{Action: internal.AuditImport, Detail: fmt.Sprintf("otpauth: %d", count)})
+		return nil
+	})
+	return count, nil
+}
+
+// OTPRemaining 条目当前的 TOTP 验证码还有多少秒失效，HOTP 返回 0
+func (a *App) OTPRemaining(id string) (int, error) {
+	vault, err := a.vaults.Snapshot()
+	if err != nil {
+		return 0, err
+	}
+	node, _, _ := vault.Find(id)
+	entry, ok := node.(*internal.Entry)
+	if !ok {
+		return 0, internal.ErrNodeNotFound
+	}
+	if entry.OTP == nil {
+		return 0, internal.ErrNoOTP
+	}
+	return entry.OTP.Remaining(time.Now()), nil
+}
+
+// SetSSHKey 设置条目是否通过内置 ssh-agent 提供私钥，key 为 nil 时不再提供
+func (a *App) SetSSHKey(id string, key *internal.SSHKey) error {
+	return a.mutate("ssh-key", func(v *internal.Vault) error {
+		return v.SetSSHKey(id, key)
+	})
+}
+
+// SSHAgentPath 内置 ssh-agent 的 socket 或命名管道路径，设置为 SSH_AUTH_SOCK 后 ssh 即可使用
+func (a *App) SSHAgentPath() string {
+	if path := a.configSnapshot().SSHAgent.Path; path != "" {
+		return path
+	}
+	return internal.DefaultSSHAgentPath()
+}
+
+// applySSHAgent 按配置启动或停止 ssh-agent，路径变化时重新监听
+func (a *App) applySSHAgent() error {
+	path := a.SSHAgentPath()
+	enabled := a.configSnapshot().SSHAgent.Enabled
+	a.agentMu.Lock()
+	defer a.agentMu.Unlock()
+	if a.agentListener != nil && (!enabled || path != a.agentPath) {
+		a.agentListener.Close()
+		a.agentListener = nil
+	}
+	if !enabled || a.agentListener != nil {
+		return nil
+	}
+	listener, err := internal.ListenSSHAgent(path)
+	if err != nil {
+		return err
+	}
+	a.agentListener, a.agentPath = listener, path
+	go a.agent.Serve(listener)
+	return nil
+}
+
+func (a *App) stopSSHAgent() {
+	a.agentMu.Lock()
+	defer a.agentMu.Unlock()
+	if a.agentListener != nil {
+		a.agentListener.Close()
+		a.agentListener = nil
+	}
+}
+
+// confirmSSHKey 显示主窗口请求用户确认签名，一分钟内没有回应视为拒绝。在 ssh-agent 的连接 goroutine 中调用
+func (a *App) confirmSSHKey(req internal.SSHKeyRequest) bool {
+	answer := make(chan bool, 1)
+	a.agentMu.Lock()
+	a.pendingSSH[req.ID] = answer
+	a.agentMu.Unlock()
+	defer func() {
+		a.agentMu.Lock()
+		delete(a.pendingSSH, req.ID)
+		a.agentMu.Unlock()
+	}()
+
+	if !a.isVisible {
+		a.ToggleWindow()
+	}
+	runtime.EventsEmit(a.ctx, "ssh-confirm", req)
+	select {
+	case allow := <-answer:
+		return allow
+	case <-time.After(time.Minute):
+		runtime.EventsEmit(a.ctx, "ssh-confirm-done", req.ID)
+		return false
+	}
+}
+
+// ApproveSSHRequest 前端对签名请求的回应
+func (a *App) ApproveSSHRequest(id string, allow bool) {
+	a.agentMu.Lock()
+	defer a.agentMu.Unlock()
+	if answer, ok := a.pendingSSH[id]; ok {
+		answer <- allow
+		delete(a.pendingSSH, id)
+	}
+}
+
+// denySSHRequests 锁定时拒绝所有等待确认的签名请求
+func (a *App) denySSHRequests() {
+	a.agentMu.Lock()
+	defer a.agentMu.Unlock()
+	for id, answer := range a.pendingSSH {
+		answer <- false
+		delete(a.pendingSSH, id)
+	}
+}
+
+// AttachFile 选择一个文件作为条目的附件，用户取消时返回 nil
+func (a *App) AttachFile(entryID string) (*internal.Attachment, error) {
+	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "选择附件",
+	})
+	if err != nil || path == "" {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	// 先按文件大小检查，避免把超大的文件读进内存
+	limits := a.configSnapshot().Attachments
+	if err := limits.CheckFile(info.Size()); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var attachment *internal.Attachment
+	err = a.mutate("attach", func(v *internal.Vault) error {
+		attachment, err = v.Attach(entryID, filepath.Base(path), data, limits)
+		return err
+	})
+	return attachment, err
+}
+
+// RemoveAttachment 从条目中移除附件
+func (a *App) RemoveAttachment(entryID string, attachmentID string) error {
+	return a.mutate("detach", func(v *internal.Vault) error {
+		return v.Detach(entryID, attachmentID)
+	})
+}
+
+// ExportAttachment 把附件解密保存到用户选择的位置，返回保存的路径；用户取消时返回空字符串
+func (a *App) ExportAttachment(entryID string, attachmentID string) (string, error) {
+	var info internal.Attachment
+	var data []byte
+	err := a.vaults.Do(func(tx *internal.VaultTx) error {
+		tx.Touch()
+		var err error
+		info, data, err = tx.Vault.Attachment(entryID, attachmentID)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "导出附件",
+		DefaultFilename: info.Name,
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+	if err := internal.WriteFileAtomic(path, data, 0600); err != nil {
+		return "", err
+	}
+	a.vaults.Do(func(tx *internal.VaultTx) error {
+		a.auditLog(tx.Keys, a.auditEntry(tx.Vault, internal.AuditExport, entryID, info.Name+": "+path))
+		return nil
+	})
+	return path, nil
+}
+
+// CopyAttachmentContents 把文本附件的内容写入剪贴板
+func (a *App) CopyAttachmentContents(entryID string, attachmentID string) error {
+	return a.vaults.Do(func(tx *internal.VaultTx) error {
+		tx.Touch()
+		info, data, err := tx.Vault.Attachment(entryID, attachmentID)
+		if err != nil {
+			return err
+		}
+		if !utf8.Valid(data) {
+			return internal.ErrAttachmentBinary
+		}
+		if err := runtime.ClipboardSetText(a.ctx, string(data)); err != nil {
+			return err
+		}
+		a.auditLog(tx.Keys, a.auditEntry(tx.Vault, internal.AuditCopy, entryID, info.Name))
+		return nil
+	})
+}
+
+func (a *App) attachmentTempDir() string {
+	return filepath.Join(os.TempDir(), "quick-clip-attachments")
+}
+
+// CopyAttachmentPath 把附件解密到临时目录（仅当前用户可读），并把文件路径写入剪贴板，
+// 便于 kubectl --kubeconfig 之类的命令直接使用。临时文件在锁定或退出时删除
+func (a *App) CopyAttachmentPath(entryID string, attachmentID string) (string, error) {
+	var path string
+	err := a.vaults.Do(func(tx *internal.VaultTx) error {
+		tx.Touch()
+		info, data, err := tx.Vault.Attachment(entryID, attachmentID)
+		if err != nil {
+			return err
+		}
+		dir := filepath.Join(a.attachmentTempDir(), info.ID)
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return err
+		}
+		path = filepath.Join(dir, info.Name)
+		if err := internal.WriteFileAtomic(path, data, 0600); err != nil {
+			return err
+		}
+		a.auditLog(tx.Keys, a.auditEntry(tx.Vault, internal.AuditExport, entryID, info.Name+": "+path))
+		return runtime.ClipboardSetText(a.ctx, path)
+	})
+	return path, err
+}
+
+// removeAttachmentTemp 删除 CopyAttachmentPath 解密出的临时文件
+func (a *App) removeAttachmentTemp() {
+	if err := os.RemoveAll(a.attachmentTempDir()); err != nil {
+		slog.Warn("删除附件临时文件失败", "err", err)
+	}
+}
+
+// ListEntryHistory 列出条目字段的旧值（从旧到新），敏感字段的值被清空，需要时用 RevealEntryHistory 单独获取
+func (a *App) ListEntryHistory(id string) ([]internal.FieldVersion, error) {
+	vault, err := a.vaults.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	node, _, _ := vault.Find(id)
+	if node == nil {
+		return nil, internal.ErrNodeNotFound
+	}
+	entry, ok := node.(*internal.Entry)
+	if !ok {
+		return nil, internal.ErrNotAnEntry
+	}
+	history := make([]internal.FieldVersion, len(entry.History))
+	for i, h := range entry.History {
+		if h.Sensitive {
+			h.Value = ""
+		}
+		history[i] = h
+	}
+	return history, nil
+}
+
+// RevealEntry 编辑框打开时取得条目的当前内容（包括 GetVault 清空的敏感字段、验证码种子和私钥），并记入审计日志
+func (a *App) RevealEntry(id string) (*internal.Entry, error) {
+	var entry *internal.Entry
+	err := a.vaults.Do(func(tx *internal.VaultTx) error {
+		tx.Touch()
+		node, _, _ := tx.Vault.Find(id)
+		e, ok := node.(*internal.Entry)
+		if !ok {
+			return internal.ErrNodeNotFound
+		}
+		a.auditLog(tx.Keys, a.auditEntry(tx.Vault, internal.AuditReveal, id, "edit"))
+		entry = e
+		return nil
+	})
+	return entry, err
+}
+
+// RevealEntryHistory 返回条目历史中第 index 个旧值的明文
+func (a *App) RevealEntryHistory(id string, index int) (string, error) {
+	var value string
+	err := a.vaults.Do(func(tx *internal.VaultTx) error {
+		tx.Touch()
+		item, err := tx.Vault.HistoryItem(id, index)
+		if err != nil {
+			return err
+		}
+		a.auditLog(tx.Keys, a.auditEntry(tx.Vault, internal.AuditReveal, id, "history: "+item.Name))
+		value = item.Value
+		return nil
+	})
+	return value, err
+}
+
+// CopyEntryHistory 把条目历史中第 index 个旧值直接写入剪贴板，明文不经过前端
+func (a *App) CopyEntryHistory(id string, index int) error {
+	return a.vaults.Do(func(tx *internal.VaultTx) error {
+		tx.Touch()
+		item, err := tx.Vault.HistoryItem(id, index)
+		if err != nil {
+			return err
+		}
+		if err := runtime.ClipboardSetText(a.ctx, item.Value); err != nil {
+			return err
+		}
+		a.auditLog(tx.Keys, a.auditEntry(tx.Vault, internal.AuditCopy, id, "history: "+item.Name))
+		return nil
+	})
+}
+
+// RestoreEntryHistory 把条目历史中第 index 个旧值写回对应字段
+func (a *App) RestoreEntryHistory(id string, index int) error {
+	return a.mutate("restore-history", func(v *internal.Vault) error {
+		return v.RestoreHistory(id, index, a.configSnapshot().History.FieldVersions)
+	})
+}
+
+// RenameNode 重命名条目或文件夹，同一文件夹下不能重名
+func (a *App) RenameNode(id string, name string) error {
+	return a.mutate("rename", func(v *internal.Vault) error {
+		return v.RenameNode(id, name)
+	})
+}
+
+// MoveNode 移动节点到 newParentID 的 index 处（按移动前的位置计算，-1 为末尾）
+func (a *App) MoveNode(id string, newParentID string, index int) error {
+	return a.mutate("move", func(v *internal.Vault) error {
+		return v.MoveNode(id, newParentID, index)
+	})
+}
+
+// DeleteNode 把条目或文件夹移入回收站
+func (a *App) DeleteNode(id string) error {
+	return a.mutate("delete", func(v *internal.Vault) error {
+		return v.DeleteNode(id)
+	})
+}
+
+// RestoreNode 把回收站中的节点恢复到原来的位置
+func (a *App) RestoreNode(id string) error {
+	return a.mutate("restore", func(v *internal.Vault) error {
+		return v.RestoreNode(id)
+	})
+}
+
+// PurgeNode 从回收站中永久删除节点
+func (a *App) PurgeNode(id string) error {
+	return a.mutate("purge", func(v *internal.Vault) error {
+		return v.PurgeNode(id)
+	})
+}
+
+// EmptyTrash 清空回收站
+func (a *App) EmptyTrash() error {
+	return a.mutate("empty-trash", func(v *internal.Vault) error {
+		v.EmptyTrash()
+		return nil
+	})
+}
+
+// TrashCount 回收站中的节点数，锁定时为 0
+func (a *App) TrashCount() int {
+	vault, err := a.vaults.Snapshot()
+	if err != nil {
+		return 0
+	}
+	return len(vault.Trash)
+}
+
+// DuplicateNode 复制节点到原节点之后，返回副本的 ID
+func (a *App) DuplicateNode(id string) (string, error) {
+	var dupID string
+	err := a.mutate("duplicate", func(v *internal.Vault) error {
+		dup, err := v.DuplicateNode(id)
+		if err == nil {
+			dupID = dup.NodeID()
+		}
+		return err
+	})
+	return dupID, err
 }
 
 func (a *App) RegisterGlobalHotkey(key1 string, key2 string) {
@@ -120,38 +1253,60 @@ func (a *App) RegisterGlobalHotkey(key1 string, key2 string) {
 // 你的热键触发逻辑
 func (a *App) ToggleWindow() {
 	if a.isVisible {
-		a.isVisible = false
-		a.action.Hide()
+		a.hide()
 	} else {
 		a.lastHwnd = a.action.RecordActiveWindow()
 		a.isVisible = true
+		a.touch()
 		a.action.ShowNoActivate()
 	}
 }
 
-func (a *App) HideWindow() {
+// hide 隐藏窗口，开启 Security.LockOnHide 时同时锁定密码库
+func (a *App) hide() {
 	a.isVisible = false
 	a.action.Hide()
+	if a.configSnapshot().Security.LockOnHide {
+		a.Lock()
+	}
 }
 
-func (a *App) PasteAndHide() {
+func (a *App) HideWindow() {
+	a.hide()
+}
+
+// PasteAndHide 把条目的 field 字段写入剪贴板，隐藏窗口、恢复焦点后粘贴。
+// field 为空时粘贴主要值或当前验证码；entryID 为空时粘贴前端已经写入剪贴板的内容
+func (a *App) PasteAndHide(entryID string, field string) error {
+	// 0. 指定了条目时由后端写入剪贴板并记入审计日志
+	if entryID != "" {
+		if err := a.copyField(entryID, field, internal.AuditPaste); err != nil {
+			return err
+		}
+	}
+
 	// 1. 隐藏窗口
-	a.isVisible = false
-	a.action.Hide()
+	a.hide()
 
 	// 2. 恢复焦点
 	a.action.RestoreFocus(a.lastHwnd)
 
 	// 3. 等待并粘贴
-	time.Sleep(time.Duration(a.config.Shortcuts.PasteWaitTime) * time.Millisecond)
+	time.Sleep(time.Duration(a.configSnapshot().Shortcuts.PasteWaitTime) * time.Millisecond)
 	go a.action.SendPaste()
+	return nil
 }
 
-// HideAndRestore 只隐藏+恢复焦点，不执行粘贴
-func (a *App) HideAndRestore() {
-	a.isVisible = false
-	a.action.Hide()
+// HideAndRestore 把条目的 field 字段写入剪贴板后只隐藏+恢复焦点，不执行粘贴。参数与 PasteAndHide 相同
+func (a *App) HideAndRestore(entryID string, field string) error {
+	if entryID != "" {
+		if err := a.copyField(entryID, field, internal.AuditCopy); err != nil {
+			return err
+		}
+	}
+	a.hide()
 	a.action.RestoreFocus(a.lastHwnd)
+	return nil
 }
 
 // 进入设置模式：变大
@@ -164,31 +1319,64 @@ func (a *App) ExitSettingsMode() {
 	a.action.SetSizeNative(320, 480)
 }
 
+// configSnapshot 返回当前配置。返回的配置不会再被修改，可以在锁外读取
+func (a *App) configSnapshot() *internal.Config {
+	a.configMu.RLock()
+	defer a.configMu.RUnlock()
+	return a.config
+}
+
 // GetConfig 供前端获取当前配置
 func (a *App) GetConfig() *internal.Config {
-	return a.config
+	return a.configSnapshot()
+}
+
+// applyRememberKey 开启 Security.RememberKey 时保存当前密钥（需已解锁），关闭时从密钥环删除
+func (a *App) applyRememberKey() error {
+	if !a.configSnapshot().Security.RememberKey {
+		header, err := internal.ReadVaultHeader(a.dataPath)
+		if err != nil {
+			return err
+		}
+		return internal.ForgetKeyInKeyring(header)
+	}
+	return a.vaults.Do(func(tx *internal.VaultTx) error {
+		return a.rememberKey(tx.Keys)
+	})
 }
 
 // UpdateConfig 供前端更新配置, 存在写入操作
 func (a *App) UpdateConfig(newCfg *internal.Config) string {
+	a.configMu.Lock()
+	rememberKey := a.config.Security.RememberKey
 	a.config = newCfg
+	a.configMu.Unlock()
 	err := a.configManager.Save(newCfg)
 	if err != nil {
 		return err.Error()
 	}
+	if err := a.applySSHAgent(); err != nil {
+		return err.Error()
+	}
+	if newCfg.Security.RememberKey != rememberKey {
+		if err := a.applyRememberKey(); err != nil {
+			return err.Error()
+		}
+	}
+	a.log.SetLevel(newCfg.Log.Level)
 	// 这里可以触发一些逻辑更新，比如修改了热键后重新注册热键
 	return "success"
 }
 
 func (a *App) SetOpacity(opacity uint8) {
-	a.config.Appearance.Opacity = opacity
+	a.configMu.Lock()
+	cfg := *a.config
+	cfg.Appearance.Opacity = opacity
+	a.config = &cfg
+	a.configMu.Unlock()
 	a.action.SetTransparency(opacity)
 }
 
 func (a *App) GetDataPath() string {
 	return a.dataPath
 }
-
-func (a *App) GetKeys() string {
-	return a.keys
-}
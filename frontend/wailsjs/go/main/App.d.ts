@@ -2,30 +2,148 @@
 // This file is automatically generated. DO NOT EDIT
 import {internal} from '../models';
 
+export function ApproveSSHRequest(arg1:string,arg2:boolean):Promise<void>;
+
+export function AttachFile(arg1:string):Promise<internal.Attachment>;
+
+export function ChangeMasterPassword(arg1:internal.Credentials,arg2:internal.Credentials):Promise<void>;
+
+export function CopyAttachmentContents(arg1:string,arg2:string):Promise<void>;
+
+export function CopyAttachmentPath(arg1:string,arg2:string):Promise<string>;
+
+export function CopyEntry(arg1:string):Promise<void>;
+
+export function CopyEntryHistory(arg1:string,arg2:number):Promise<void>;
+
+export function CreateEntry(arg1:string,arg2:string,arg3:Array<internal.Field>):Promise<string>;
+
+export function CreateFolder(arg1:string,arg2:string):Promise<string>;
+
+export function DeleteNode(arg1:string):Promise<void>;
+
+export function DuplicateNode(arg1:string):Promise<string>;
+
+export function EmptyTrash():Promise<void>;
+
 export function EnterSettingsMode():Promise<void>;
 
 export function ExitSettingsMode():Promise<void>;
 
+export function ExpiryReport():Promise<Array<internal.ExpiryItem>>;
+
+export function ExportAttachment(arg1:string,arg2:string):Promise<string>;
+
+export function ExportContent():Promise<string>;
+
+export function Favourites():Promise<Array<internal.Entry>>;
+
+export function GenerateKeyFile():Promise<string>;
+
+export function GeneratePassword(arg1:internal.GeneratorPolicy):Promise<string>;
+
+export function GeneratorPolicyFor(arg1:string):Promise<internal.GeneratorPolicy>;
+
 export function GetConfig():Promise<internal.Config>;
 
 export function GetContent():Promise<Array<any>>;
 
 export function GetDataPath():Promise<string>;
 
-export function GetKeys():Promise<string>;
+export function GetSafeMode():Promise<internal.SafeModeInfo>;
 
-export function HideAndRestore():Promise<void>;
+export function GetSecurityReport():Promise<internal.SecurityReport>;
+
+export function GetVault():Promise<internal.Vault>;
+
+export function HideAndRestore(arg1:string,arg2:string):Promise<void>;
 
 export function HideWindow():Promise<void>;
 
-export function PasteAndHide():Promise<void>;
+export function ImportContent():Promise<void>;
+
+export function ImportOTP(arg1:string,arg2:string):Promise<number>;
+
+export function IsUnlocked():Promise<boolean>;
+
+export function IsVaultCreated():Promise<boolean>;
+
+export function KeyringSupported():Promise<boolean>;
+
+export function KeyringUnlockAvailable():Promise<boolean>;
+
+export function ListAuditLog(arg1:internal.AuditFilter):Promise<Array<internal.AuditRecord>>;
+
+export function ListBackups():Promise<Array<internal.BackupInfo>>;
+
+export function ListEntryHistory(arg1:string):Promise<Array<internal.FieldVersion>>;
+
+export function Lock():Promise<void>;
+
+export function MoveNode(arg1:string,arg2:string,arg3:number):Promise<void>;
+
+export function OTPRemaining(arg1:string):Promise<number>;
+
+export function ParseOTPURI(arg1:string):Promise<internal.OTP>;
+
+export function PasteAndHide(arg1:string,arg2:string):Promise<void>;
+
+export function PreviewBackup(arg1:string):Promise<Array<any>>;
+
+export function PurgeNode(arg1:string):Promise<void>;
+
+export function Redo():Promise<string>;
 
 export function RegisterGlobalHotkey(arg1:string,arg2:string):Promise<void>;
 
+export function RemoveAttachment(arg1:string,arg2:string):Promise<void>;
+
+export function RenameNode(arg1:string,arg2:string):Promise<void>;
+
+export function ResetVault():Promise<void>;
+
+export function RestoreBackup(arg1:string):Promise<void>;
+
+export function RestoreEntryHistory(arg1:string,arg2:number):Promise<void>;
+
+export function RestoreNode(arg1:string):Promise<void>;
+
+export function RevealEntry(arg1:string):Promise<internal.Entry>;
+
+export function RevealEntryHistory(arg1:string,arg2:number):Promise<string>;
+
+export function SSHAgentPath():Promise<string>;
+
 export function SaveContent(arg1:Array<any>):Promise<void>;
 
+export function SelectKeyFile():Promise<string>;
+
+export function SetExpiry(arg1:string,arg2:any,arg3:number):Promise<void>;
+
+export function SetFolderGeneratorPolicy(arg1:string,arg2:internal.GeneratorPolicy):Promise<void>;
+
+export function SetLabels(arg1:string,arg2:internal.Labels):Promise<void>;
+
+export function SetOTP(arg1:string,arg2:internal.OTP):Promise<void>;
+
 export function SetOpacity(arg1:number):Promise<void>;
 
+export function SetSSHKey(arg1:string,arg2:internal.SSHKey):Promise<void>;
+
 export function ToggleWindow():Promise<void>;
 
+export function TrashCount():Promise<number>;
+
+export function Undo():Promise<string>;
+
+export function Unlock(arg1:internal.Credentials):Promise<void>;
+
+export function UnlockWithKeyring():Promise<void>;
+
 export function UpdateConfig(arg1:internal.Config):Promise<string>;
+
+export function UpdateEntry(arg1:string,arg2:Array<internal.Field>,arg3:string):Promise<void>;
+
+export function VaultRequiresKeyFile():Promise<boolean>;
+
+export function VerifyAuditLog():Promise<internal.AuditStatus>;
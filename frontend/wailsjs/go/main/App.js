@@ -2,6 +2,54 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function ApproveSSHRequest(arg1, arg2) {
+  return window['go']['main']['App']['ApproveSSHRequest'](arg1, arg2);
+}
+
+export function AttachFile(arg1) {
+  return window['go']['main']['App']['AttachFile'](arg1);
+}
+
+export function ChangeMasterPassword(arg1, arg2) {
+  return window['go']['main']['App']['ChangeMasterPassword'](arg1, arg2);
+}
+
+export function CopyAttachmentContents(arg1, arg2) {
+  return window['go']['main']['App']['CopyAttachmentContents'](arg1, arg2);
+}
+
+export function CopyAttachmentPath(arg1, arg2) {
+  return window['go']['main']['App']['CopyAttachmentPath'](arg1, arg2);
+}
+
+export function CopyEntry(arg1) {
+  return window['go']['main']['App']['CopyEntry'](arg1);
+}
+
+export function CopyEntryHistory(arg1, arg2) {
+  return window['go']['main']['App']['CopyEntryHistory'](arg1, arg2);
+}
+
+export function CreateEntry(arg1, arg2, arg3) {
+  return window['go']['main']['App']['CreateEntry'](arg1, arg2, arg3);
+}
+
+export function CreateFolder(arg1, arg2) {
+  return window['go']['main']['App']['CreateFolder'](arg1, arg2);
+}
+
+export function DeleteNode(arg1) {
+  return window['go']['main']['App']['DeleteNode'](arg1);
+}
+
+export function DuplicateNode(arg1) {
+  return window['go']['main']['App']['DuplicateNode'](arg1);
+}
+
+export function EmptyTrash() {
+  return window['go']['main']['App']['EmptyTrash']();
+}
+
 export function EnterSettingsMode() {
   return window['go']['main']['App']['EnterSettingsMode']();
 }
@@ -10,6 +58,34 @@ export function ExitSettingsMode() {
   return window['go']['main']['App']['ExitSettingsMode']();
 }
 
+export function ExpiryReport() {
+  return window['go']['main']['App']['ExpiryReport']();
+}
+
+export function ExportAttachment(arg1, arg2) {
+  return window['go']['main']['App']['ExportAttachment'](arg1, arg2);
+}
+
+export function ExportContent() {
+  return window['go']['main']['App']['ExportContent']();
+}
+
+export function Favourites() {
+  return window['go']['main']['App']['Favourites']();
+}
+
+export function GenerateKeyFile() {
+  return window['go']['main']['App']['GenerateKeyFile']();
+}
+
+export function GeneratePassword(arg1) {
+  return window['go']['main']['App']['GeneratePassword'](arg1);
+}
+
+export function GeneratorPolicyFor(arg1) {
+  return window['go']['main']['App']['GeneratorPolicyFor'](arg1);
+}
+
 export function GetConfig() {
   return window['go']['main']['App']['GetConfig']();
 }
@@ -22,38 +98,198 @@ export function GetDataPath() {
   return window['go']['main']['App']['GetDataPath']();
 }
 
-export function GetKeys() {
-  return window['go']['main']['App']['GetKeys']();
+export function GetSafeMode() {
+  return window['go']['main']['App']['GetSafeMode']();
 }
 
-export function HideAndRestore() {
-  return window['go']['main']['App']['HideAndRestore']();
+export function GetSecurityReport() {
+  return window['go']['main']['App']['GetSecurityReport']();
+}
+
+export function GetVault() {
+  return window['go']['main']['App']['GetVault']();
+}
+
+export function HideAndRestore(arg1, arg2) {
+  return window['go']['main']['App']['HideAndRestore'](arg1, arg2);
 }
 
 export function HideWindow() {
   return window['go']['main']['App']['HideWindow']();
 }
 
-export function PasteAndHide() {
-  return window['go']['main']['App']['PasteAndHide']();
+export function ImportContent() {
+  return window['go']['main']['App']['ImportContent']();
+}
+
+export function ImportOTP(arg1, arg2) {
+  return window['go']['main']['App']['ImportOTP'](arg1, arg2);
+}
+
+export function IsUnlocked() {
+  return window['go']['main']['App']['IsUnlocked']();
+}
+
+export function IsVaultCreated() {
+  return window['go']['main']['App']['IsVaultCreated']();
+}
+
+export function KeyringSupported() {
+  return window['go']['main']['App']['KeyringSupported']();
+}
+
+export function KeyringUnlockAvailable() {
+  return window['go']['main']['App']['KeyringUnlockAvailable']();
+}
+
+export function ListAuditLog(arg1) {
+  return window['go']['main']['App']['ListAuditLog'](arg1);
+}
+
+export function ListBackups() {
+  return window['go']['main']['App']['ListBackups']();
+}
+
+export function ListEntryHistory(arg1) {
+  return window['go']['main']['App']['ListEntryHistory'](arg1);
+}
+
+export function Lock() {
+  return window['go']['main']['App']['Lock']();
+}
+
+export function MoveNode(arg1, arg2, arg3) {
+  return window['go']['main']['App']['MoveNode'](arg1, arg2, arg3);
+}
+
+export function OTPRemaining(arg1) {
+  return window['go']['main']['App']['OTPRemaining'](arg1);
+}
+
+export function ParseOTPURI(arg1) {
+  return window['go']['main']['App']['ParseOTPURI'](arg1);
+}
+
+export function PasteAndHide(arg1, arg2) {
+  return window['go']['main']['App']['PasteAndHide'](arg1, arg2);
+}
+
+export function PreviewBackup(arg1) {
+  return window['go']['main']['App']['PreviewBackup'](arg1);
+}
+
+export function PurgeNode(arg1) {
+  return window['go']['main']['App']['PurgeNode'](arg1);
+}
+
+export function Redo() {
+  return window['go']['main']['App']['Redo']();
 }
 
 export function RegisterGlobalHotkey(arg1, arg2) {
   return window['go']['main']['App']['RegisterGlobalHotkey'](arg1, arg2);
 }
 
+export function RemoveAttachment(arg1, arg2) {
+  return window['go']['main']['App']['RemoveAttachment'](arg1, arg2);
+}
+
+export function RenameNode(arg1, arg2) {
+  return window['go']['main']['App']['RenameNode'](arg1, arg2);
+}
+
+export function ResetVault() {
+  return window['go']['main']['App']['ResetVault']();
+}
+
+export function RestoreBackup(arg1) {
+  return window['go']['main']['App']['RestoreBackup'](arg1);
+}
+
+export function RestoreEntryHistory(arg1, arg2) {
+  return window['go']['main']['App']['RestoreEntryHistory'](arg1, arg2);
+}
+
+export function RestoreNode(arg1) {
+  return window['go']['main']['App']['RestoreNode'](arg1);
+}
+
+export function RevealEntry(arg1) {
+  return window['go']['main']['App']['RevealEntry'](arg1);
+}
+
+export function RevealEntryHistory(arg1, arg2) {
+  return window['go']['main']['App']['RevealEntryHistory'](arg1, arg2);
+}
+
+export function SSHAgentPath() {
+  return window['go']['main']['App']['SSHAgentPath']();
+}
+
 export function SaveContent(arg1) {
   return window['go']['main']['App']['SaveContent'](arg1);
 }
 
+export function SelectKeyFile() {
+  return window['go']['main']['App']['SelectKeyFile']();
+}
+
+export function SetExpiry(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetExpiry'](arg1, arg2, arg3);
+}
+
+export function SetFolderGeneratorPolicy(arg1, arg2) {
+  return window['go']['main']['App']['SetFolderGeneratorPolicy'](arg1, arg2);
+}
+
+export function SetLabels(arg1, arg2) {
+  return window['go']['main']['App']['SetLabels'](arg1, arg2);
+}
+
+export function SetOTP(arg1, arg2) {
+  return window['go']['main']['App']['SetOTP'](arg1, arg2);
+}
+
 export function SetOpacity(arg1) {
   return window['go']['main']['App']['SetOpacity'](arg1);
 }
 
+export function SetSSHKey(arg1, arg2) {
+  return window['go']['main']['App']['SetSSHKey'](arg1, arg2);
+}
+
 export function ToggleWindow() {
   return window['go']['main']['App']['ToggleWindow']();
 }
 
+export function TrashCount() {
+  return window['go']['main']['App']['TrashCount']();
+}
+
+export function Undo() {
+  return window['go']['main']['App']['Undo']();
+}
+
+export function Unlock(arg1) {
+  return window['go']['main']['App']['Unlock'](arg1);
+}
+
+export function UnlockWithKeyring() {
+  return window['go']['main']['App']['UnlockWithKeyring']();
+}
+
 export function UpdateConfig(arg1) {
   return window['go']['main']['App']['UpdateConfig'](arg1);
 }
+
+export function UpdateEntry(arg1, arg2, arg3) {
+  return window['go']['main']['App']['UpdateEntry'](arg1, arg2, arg3);
+}
+
+export function VaultRequiresKeyFile() {
+  return window['go']['main']['App']['VaultRequiresKeyFile']();
+}
+
+export function VerifyAuditLog() {
+  return window['go']['main']['App']['VerifyAuditLog']();
+}
@@ -1,5 +1,125 @@
 export namespace internal {
 	
+	export class AttachmentConfig {
+	    maxFileKB: number;
+	    maxTotalKB: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AttachmentConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.maxFileKB = source["maxFileKB"];
+	        this.maxTotalKB = source["maxTotalKB"];
+	    }
+	}
+	export class BackupConfig {
+	    keepLast: number;
+	    keepDaily: number;
+	    keepWeekly: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new BackupConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.keepLast = source["keepLast"];
+	        this.keepDaily = source["keepDaily"];
+	        this.keepWeekly = source["keepWeekly"];
+	    }
+	}
+	export class GeneratorPolicy {
+	    mode: string;
+	    length: number;
+	    lower: boolean;
+	    upper: boolean;
+	    digits: boolean;
+	    symbols: boolean;
+	    excludeAmbiguous: boolean;
+	    requireEach: boolean;
+	    words: number;
+	    separator: string;
+	    capitalize: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new GeneratorPolicy(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.mode = source["mode"];
+	        this.length = source["length"];
+	        this.lower = source["lower"];
+	        this.upper = source["upper"];
+	        this.digits = source["digits"];
+	        this.symbols = source["symbols"];
+	        this.excludeAmbiguous = source["excludeAmbiguous"];
+	        this.requireEach = source["requireEach"];
+	        this.words = source["words"];
+	        this.separator = source["separator"];
+	        this.capitalize = source["capitalize"];
+	    }
+	}
+	export class ExpiryConfig {
+	    warnDays: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExpiryConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.warnDays = source["warnDays"];
+	    }
+	}
+	export class HistoryConfig {
+	    limit: number;
+	    persist: boolean;
+	    fieldVersions: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new HistoryConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.limit = source["limit"];
+	        this.persist = source["persist"];
+	        this.fieldVersions = source["fieldVersions"];
+	    }
+	}
+	export class TrashConfig {
+	    purgeDays: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TrashConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.purgeDays = source["purgeDays"];
+	    }
+	}
+	export class SecurityConfig {
+	    autoLockMinutes: number;
+	    lockOnHide: boolean;
+	    rememberKey: boolean;
+	    oldSecretDays: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SecurityConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.autoLockMinutes = source["autoLockMinutes"];
+	        this.lockOnHide = source["lockOnHide"];
+	        this.rememberKey = source["rememberKey"];
+	        this.oldSecretDays = source["oldSecretDays"];
+	    }
+	}
 	export class AppearanceConfig {
 	    opacity: number;
 	
@@ -38,10 +158,49 @@ export namespace internal {
 	        this.launchAtLogin = source["launchAtLogin"];
 	    }
 	}
+	export class SSHAgentConfig {
+	    enabled: boolean;
+	    path: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SSHAgentConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.path = source["path"];
+	    }
+	}
+	export class LogConfig {
+	    level: string;
+	    maxSizeMB: number;
+	    maxFiles: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new LogConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.level = source["level"];
+	        this.maxSizeMB = source["maxSizeMB"];
+	        this.maxFiles = source["maxFiles"];
+	    }
+	}
 	export class Config {
 	    general: GeneralConfig;
 	    shortcuts: ShortcutsConfig;
 	    appearance: AppearanceConfig;
+	    security: SecurityConfig;
+	    backup: BackupConfig;
+	    trash: TrashConfig;
+	    history: HistoryConfig;
+	    attachments: AttachmentConfig;
+	    expiry: ExpiryConfig;
+	    generator: GeneratorPolicy;
+	    sshAgent: SSHAgentConfig;
+	    log: LogConfig;
 	
 	    static createFrom(source: any = {}) {
 	        return new Config(source);
@@ -52,6 +211,559 @@ export namespace internal {
 	        this.general = this.convertValues(source["general"], GeneralConfig);
 	        this.shortcuts = this.convertValues(source["shortcuts"], ShortcutsConfig);
 	        this.appearance = this.convertValues(source["appearance"], AppearanceConfig);
+	        this.security = this.convertValues(source["security"], SecurityConfig);
+	        this.backup = this.convertValues(source["backup"], BackupConfig);
+	        this.trash = this.convertValues(source["trash"], TrashConfig);
+	        this.history = this.convertValues(source["history"], HistoryConfig);
+	        this.attachments = this.convertValues(source["attachments"], AttachmentConfig);
+	        this.expiry = this.convertValues(source["expiry"], ExpiryConfig);
+	        this.generator = this.convertValues(source["generator"], GeneratorPolicy);
+	        this.sshAgent = this.convertValues(source["sshAgent"], SSHAgentConfig);
+	        this.log = this.convertValues(source["log"], LogConfig);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Attachment {
+	    id: string;
+	    name: string;
+	    size: number;
+	    // Go type: time
+	    added: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Attachment(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.size = source["size"];
+	        this.added = this.convertValues(source["added"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class AuditFilter {
+	    action: string;
+	    entryId: string;
+	    query: string;
+	    // Go type: time
+	    from?: any;
+	    // Go type: time
+	    to?: any;
+	    limit: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AuditFilter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.action = source["action"];
+	        this.entryId = source["entryId"];
+	        this.query = source["query"];
+	        this.from = this.convertValues(source["from"], null);
+	        this.to = this.convertValues(source["to"], null);
+	        this.limit = source["limit"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class AuditRecord {
+	    seq: number;
+	    // Go type: time
+	    time: any;
+	    action: string;
+	    entryId: string;
+	    name: string;
+	    detail: string;
+	    prev: string;
+	    hash: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new AuditRecord(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.seq = source["seq"];
+	        this.time = this.convertValues(source["time"], null);
+	        this.action = source["action"];
+	        this.entryId = source["entryId"];
+	        this.name = source["name"];
+	        this.detail = source["detail"];
+	        this.prev = source["prev"];
+	        this.hash = source["hash"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class AuditStatus {
+	    valid: boolean;
+	    records: number;
+	    problem: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new AuditStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.valid = source["valid"];
+	        this.records = source["records"];
+	        this.problem = source["problem"];
+	    }
+	}
+	export class BackupInfo {
+	    id: string;
+	    // Go type: time
+	    time: any;
+	    size: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new BackupInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.time = this.convertValues(source["time"], null);
+	        this.size = source["size"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Credentials {
+	    password: string;
+	    keyFile: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Credentials(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.password = source["password"];
+	        this.keyFile = source["keyFile"];
+	    }
+	}
+	export class ExpiryItem {
+	    id: string;
+	    name: string;
+	    path: string[];
+	    // Go type: time
+	    expiresAt: any;
+	    expired: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExpiryItem(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.path = source["path"];
+	        this.expiresAt = this.convertValues(source["expiresAt"], null);
+	        this.expired = source["expired"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class AgeItem {
+	    id: string;
+	    name: string;
+	    path: string[];
+	    field: string;
+	    // Go type: time
+	    changed: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new AgeItem(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.path = source["path"];
+	        this.field = source["field"];
+	        this.changed = this.convertValues(source["changed"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ReportRef {
+	    id: string;
+	    name: string;
+	    path: string[];
+	    field: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ReportRef(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.path = source["path"];
+	        this.field = source["field"];
+	    }
+	}
+	export class StrengthMatch {
+	    pattern: string;
+	    dictionary: string;
+	    length: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new StrengthMatch(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.pattern = source["pattern"];
+	        this.dictionary = source["dictionary"];
+	        this.length = source["length"];
+	    }
+	}
+	export class StrengthItem {
+	    id: string;
+	    name: string;
+	    path: string[];
+	    field: string;
+	    score: number;
+	    entropy: number;
+	    crackTime: string;
+	    weak: boolean;
+	    matches: StrengthMatch[];
+	
+	    static createFrom(source: any = {}) {
+	        return new StrengthItem(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.path = source["path"];
+	        this.field = source["field"];
+	        this.score = source["score"];
+	        this.entropy = source["entropy"];
+	        this.crackTime = source["crackTime"];
+	        this.weak = source["weak"];
+	        this.matches = this.convertValues(source["matches"], StrengthMatch);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SecurityReport {
+	    // Go type: time
+	    generated: any;
+	    checked: number;
+	    strength: StrengthItem[];
+	    reused: ReportRef[][];
+	    old: AgeItem[];
+	    empty: ReportRef[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SecurityReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.generated = this.convertValues(source["generated"], null);
+	        this.checked = source["checked"];
+	        this.strength = this.convertValues(source["strength"], StrengthItem);
+	        this.reused = this.convertValues(source["reused"], ReportRef);
+	        this.old = this.convertValues(source["old"], AgeItem);
+	        this.empty = this.convertValues(source["empty"], ReportRef);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Field {
+	    name: string;
+	    value: string;
+	    sensitive: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Field(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.value = source["value"];
+	        this.sensitive = source["sensitive"];
+	    }
+	}
+	export class FieldVersion {
+	    name: string;
+	    value: string;
+	    sensitive: boolean;
+	    // Go type: time
+	    changed: any;
+	    reason?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new FieldVersion(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.value = source["value"];
+	        this.sensitive = source["sensitive"];
+	        this.changed = this.convertValues(source["changed"], null);
+	        this.reason = source["reason"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class OTP {
+	    type: string;
+	    secret: string;
+	    algorithm: string;
+	    digits: number;
+	    period?: number;
+	    counter?: number;
+	    issuer?: string;
+	    account?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new OTP(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.type = source["type"];
+	        this.secret = source["secret"];
+	        this.algorithm = source["algorithm"];
+	        this.digits = source["digits"];
+	        this.period = source["period"];
+	        this.counter = source["counter"];
+	        this.issuer = source["issuer"];
+	        this.account = source["account"];
+	    }
+	}
+	export class SSHKey {
+	    field: string;
+	    confirm?: boolean;
+	    lifetimeMinutes?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SSHKey(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.field = source["field"];
+	        this.confirm = source["confirm"];
+	        this.lifetimeMinutes = source["lifetimeMinutes"];
+	    }
+	}
+	export class Entry {
+	    id: string;
+	    name: string;
+	    fields: Field[];
+	    history?: FieldVersion[];
+	    attachments?: Attachment[];
+	    // Go type: time
+	    expiresAt?: any;
+	    rotationDays?: number;
+	    otp?: OTP;
+	    sshKey?: SSHKey;
+	    tags?: string[];
+	    favourite?: boolean;
+	    color?: string;
+	    // Go type: time
+	    created: any;
+	    // Go type: time
+	    modified: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Entry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.fields = this.convertValues(source["fields"], Field);
+	        this.history = this.convertValues(source["history"], FieldVersion);
+	        this.attachments = this.convertValues(source["attachments"], Attachment);
+	        this.expiresAt = this.convertValues(source["expiresAt"], null);
+	        this.rotationDays = source["rotationDays"];
+	        this.otp = this.convertValues(source["otp"], OTP);
+	        this.sshKey = this.convertValues(source["sshKey"], SSHKey);
+	        this.tags = source["tags"];
+	        this.favourite = source["favourite"];
+	        this.color = source["color"];
+	        this.created = this.convertValues(source["created"], null);
+	        this.modified = this.convertValues(source["modified"], null);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -72,7 +784,183 @@ export namespace internal {
 		    return a;
 		}
 	}
+	export class Folder {
+	    id: string;
+	    name: string;
+	    tags?: string[];
+	    favourite?: boolean;
+	    color?: string;
+	    generator?: GeneratorPolicy;
+	    // Go type: time
+	    created: any;
+	    // Go type: time
+	    modified: any;
+	    children: any[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Folder(source);
+	    }
 	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.tags = source["tags"];
+	        this.favourite = source["favourite"];
+	        this.color = source["color"];
+	        this.generator = this.convertValues(source["generator"], GeneratorPolicy);
+	        this.created = this.convertValues(source["created"], null);
+	        this.modified = this.convertValues(source["modified"], null);
+	        this.children = source["children"];
+	    }
 
-}
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Labels {
+	    tags?: string[];
+	    favourite?: boolean;
+	    color?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Labels(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.tags = source["tags"];
+	        this.favourite = source["favourite"];
+	        this.color = source["color"];
+	    }
+	}
+	export class SafeModeInfo {
+	    kind: string;
+	    message: string;
+	    quarantinePath: string;
+	    // Go type: time
+	    time: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new SafeModeInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.kind = source["kind"];
+	        this.message = source["message"];
+	        this.quarantinePath = source["quarantinePath"];
+	        this.time = this.convertValues(source["time"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TrashItem {
+	    node: any;
+	    parentId: string;
+	    path: string[];
+	    index: number;
+	    // Go type: time
+	    deleted: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new TrashItem(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.node = source["node"];
+	        this.parentId = source["parentId"];
+	        this.path = source["path"];
+	        this.index = source["index"];
+	        this.deleted = this.convertValues(source["deleted"], null);
+	    }
+
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Vault {
+	    version: number;
+	    root?: Folder;
+	    trash: TrashItem[];
+	    blobs?: {[key: string]: number[]};
+	    auditSeq?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Vault(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
+	        this.root = this.convertValues(source["root"], Folder);
+	        this.trash = this.convertValues(source["trash"], TrashItem);
+	        this.blobs = source["blobs"];
+	        this.auditSeq = source["auditSeq"];
+	    }
 
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
@@ -3,7 +3,7 @@ package internal
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"log/slog"
 	"os"
 	"syscall"
 	"time"
@@ -70,7 +70,7 @@ func (a *Action) SetSelfHwnd(hwnd win.HWND) {
 	var buf [256]uint16
 	win.GetWindowText(hwnd, &buf[0], 256)
 	title := syscall.UTF16ToString(buf[:])
-	fmt.Printf("绑定句柄: %d, 标题: %s\n", hwnd, title)
+	slog.Debug("绑定句柄", "hwnd", hwnd, "title", title)
 	a.selfHwnd = hwnd
 }
 
@@ -260,7 +260,7 @@ func (a *Action) SendPaste() {
 	// 松开 Ctrl
 	keybd.Call(uintptr(VK_CONTROL), 0, KEYUP, 0)
 
-	fmt.Println("粘贴指令已发送")
+	slog.Debug("粘贴指令已发送")
 }
 
 func (a *Action) SetSizeNative(width, height int) {
@@ -302,10 +302,11 @@ func (a *Action) SetTransparency(alpha uint8) {
 	)
 }
 
-func (a *Action) ExportJson(content []any, ctx context.Context) {
+// ExportJson 把 content 以明文 JSON 保存到用户选择的文件，返回保存的路径；用户取消时返回空字符串
+func (a *Action) ExportJson(content []any, ctx context.Context) (string, error) {
 	byteData, err := json.Marshal(content)
-	if err != nil || byteData == nil {
-		return
+	if err != nil {
+		return "", err
 	}
 
 	filePath, err := runtime.SaveFileDialog(ctx, runtime.SaveDialogOptions{
@@ -320,23 +321,19 @@ func (a *Action) ExportJson(content []any, ctx context.Context) {
 		},
 	})
 
-	if err != nil {
-		return
+	if err != nil || filePath == "" {
+		return "", err
 	}
 
-	if filePath == "" {
-		return
+	// 写入文件，明文只允许当前用户读取
+	if err := os.WriteFile(filePath, byteData, 0600); err != nil {
+		return "", err
 	}
-
-	// 写入文件
-	err = os.WriteFile(filePath, byteData, 0644)
-	if err != nil {
-		return
-	}
-
+	return filePath, nil
 }
 
-func (a *Action) ImportJson(ctx context.Context) []any {
+// ImportJson 读取用户选择的明文 JSON 文件，返回内容和文件路径；用户取消或读取失败时内容为 nil
+func (a *Action) ImportJson(ctx context.Context) ([]any, string) {
 	filePath, err := runtime.OpenFileDialog(ctx, runtime.OpenDialogOptions{
 		Title:            "导入密码文件(明文)",
 		DefaultDirectory: "",
@@ -349,23 +346,23 @@ func (a *Action) ImportJson(ctx context.Context) []any {
 	})
 
 	if err != nil {
-		return nil
+		return nil, ""
 	}
 
 	if filePath == "" {
-		return nil
+		return nil, ""
 	}
 
 	// 读取文件
 	byteData, err := os.ReadFile(filePath)
 	if err != nil {
-		return nil
+		return nil, ""
 	}
 
 	var content []any
 	err = json.Unmarshal(byteData, &content)
 	if err != nil {
-		return nil
+		return nil, ""
 	}
-	return content
+	return content, filePath
 }
@@ -0,0 +1,35 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomic 先写入同目录下的临时文件并 fsync，再原子地改名覆盖 path。
+// 任意时刻崩溃，path 要么是旧内容，要么是完整的新内容
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	// 改名成功后 tmpPath 已不存在，Remove 只会清理失败时留下的临时文件
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	return RenameDurable(tmpPath, path)
+}
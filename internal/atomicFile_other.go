@@ -0,0 +1,21 @@
+//go:build !windows
+
+package internal
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// RenameDurable 改名后 fsync 所在目录，保证目录项的变更已经落盘
+func RenameDurable(oldPath string, newPath string) error {
+	if err := os.Rename(oldPath, newPath); err != nil {
+		return err
+	}
+	dir, err := os.Open(filepath.Dir(newPath))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
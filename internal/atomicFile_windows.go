@@ -0,0 +1,19 @@
+//go:build windows
+
+package internal
+
+import "golang.org/x/sys/windows"
+
+// RenameDurable 用 MOVEFILE_WRITE_THROUGH 改名，函数返回时改名已经落盘。
+// Windows 不支持对目录 fsync，这是对应的做法
+func RenameDurable(oldPath string, newPath string) error {
+	from, err := windows.UTF16PtrFromString(oldPath)
+	if err != nil {
+		return err
+	}
+	to, err := windows.UTF16PtrFromString(newPath)
+	if err != nil {
+		return err
+	}
+	return windows.MoveFileEx(from, to, windows.MOVEFILE_REPLACE_EXISTING|windows.MOVEFILE_WRITE_THROUGH)
+}
@@ -0,0 +1,140 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrAttachmentTooLarge = errors.New("attachment exceeds the size limit")
+	ErrAttachmentBinary   = errors.New("attachment is not text")
+)
+
+// Attachment 条目的附件。内容按 ID 保存在 Vault.Blobs 中，随密码库一起加密、备份和导出。
+// 内容不可变，复制条目或撤销修改时多个条目可以引用同一份内容
+type Attachment struct {
+	ID    string    `json:"id"`
+	Name  string    `json:"name"`
+	Size  int64     `json:"size"`
+	Added time.Time `json:"added"`
+}
+
+// CheckFile 单个附件是否超过大小限制，读取文件前先检查
+func (c AttachmentConfig) CheckFile(size int64) error {
+	if c.MaxFileKB > 0 && size > int64(c.MaxFileKB)*1024 {
+		return fmt.Errorf("%w: %d KB per file", ErrAttachmentTooLarge, c.MaxFileKB)
+	}
+	return nil
+}
+
+// Attach 给条目添加附件，name 只保留文件名部分
+func (v *Vault) Attach(entryID string, name string, data []byte, limits AttachmentConfig) (*Attachment, error) {
+	entry, err := v.entry(entryID)
+	if err != nil {
+		return nil, err
+	}
+	name = filepath.Base(name)
+	if strings.TrimSpace(name) == "" || name == "." {
+		return nil, ErrEmptyName
+	}
+	if err := limits.CheckFile(int64(len(data))); err != nil {
+		return nil, err
+	}
+	if limits.MaxTotalKB > 0 {
+		total := int64(len(data))
+		for _, blob := range v.Blobs {
+			total += int64(len(blob))
+		}
+		if total > int64(limits.MaxTotalKB)*1024 {
+			return nil, fmt.Errorf("%w: %d KB in total", ErrAttachmentTooLarge, limits.MaxTotalKB)
+		}
+	}
+
+	now := time.Now()
+	attachment := Attachment{ID: newID(), Name: name, Size: int64(len(data)), Added: now}
+	if v.Blobs == nil {
+		v.Blobs = make(map[string][]byte)
+	}
+	v.Blobs[attachment.ID] = data
+	entry.Attachments = append(entry.Attachments, attachment)
+	entry.Modified = now
+	return &attachment, nil
+}
+
+// Detach 从条目中移除附件，内容由 PruneBlobs 清理
+func (v *Vault) Detach(entryID string, attachmentID string) error {
+	entry, err := v.entry(entryID)
+	if err != nil {
+		return err
+	}
+	for i, a := range entry.Attachments {
+		if a.ID == attachmentID {
+			entry.Attachments = append(entry.Attachments[:i:i], entry.Attachments[i+1:]...)
+			entry.Modified = time.Now()
+			return nil
+		}
+	}
+	return ErrAttachmentNotFound
+}
+
+// Attachment 返回附件信息和内容
+func (v *Vault) Attachment(entryID string, attachmentID string) (Attachment, []byte, error) {
+	entry, err := v.entry(entryID)
+	if err != nil {
+		return Attachment{}, nil, err
+	}
+	for _, a := range entry.Attachments {
+		if a.ID == attachmentID {
+			data, ok := v.Blobs[a.ID]
+			if !ok {
+				return Attachment{}, nil, ErrAttachmentNotFound
+			}
+			return a, data, nil
+		}
+	}
+	return Attachment{}, nil, ErrAttachmentNotFound
+}
+
+// WithoutBlobs 返回不含附件内容的浅拷贝，用于传给前端或记录快照
+func (v *Vault) WithoutBlobs() *Vault {
+	shallow := *v
+	shallow.Blobs = nil
+	return &shallow
+}
+
+// referencedBlobs 目录树和回收站中所有条目引用的附件 ID
+func (v *Vault) referencedBlobs() map[string]bool {
+	ids := make(map[string]bool)
+	var walk func(node Node)
+	walk = func(node Node) {
+		switch n := node.(type) {
+		case *Entry:
+			for _, a := range n.Attachments {
+				ids[a.ID] = true
+			}
+		case *Folder:
+			for _, child := range n.Children {
+				walk(child)
+			}
+		}
+	}
+	walk(v.Root)
+	for _, item := range v.Trash {
+		walk(item.Node)
+	}
+	return ids
+}
+
+// PruneBlobs 删除不再被任何条目（包括回收站中的）引用的附件内容
+func (v *Vault) PruneBlobs() {
+	ids := v.referencedBlobs()
+	for id := range v.Blobs {
+		if !ids[id] {
+			delete(v.Blobs, id)
+		}
+	}
+}
@@ -0,0 +1,499 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 审计日志记录的操作
+const (
+	AuditReveal = "reveal" // 在界面上显示明文
+	AuditCopy   = "copy"   // 写入剪贴板
+	AuditPaste  = "paste"  // 写入剪贴板并粘贴到之前的窗口
+	AuditEdit   = "edit"   // 条目被新建、修改或删除
+	AuditExport = "export" // 以明文导出到文件
+	AuditImport = "import" // 从文件或 URI 导入
+)
+
+var ErrAuditTampered = errors.New("audit log has been modified")
+
+// AuditRecord 审计日志中的一条记录，只记录发生了什么，不包含任何明文
+type AuditRecord struct {
+	Seq     int       `json:"seq"` // 从 1 开始连续递增
+	Time    time.Time `json:"time"`
+	Action  string    `json:"action"`
+	EntryID string    `json:"entryId"`
+	Name    string    `json:"name"`   // 记录时的条目名
+	Detail  string    `json:"detail"` // 字段名、修改操作名或导出路径
+	Prev    string    `json:"prev"`   // 上一条记录的 Hash，第一条为空
+	Hash    string    `json:"hash"`   // 本条记录（Hash 为空时）JSON 的 SHA-256
+}
+
+// digest 计算记录的哈希，Hash 字段本身不参与计算
+func (r AuditRecord) digest() string {
+	r.Hash = ""
+	data, _ := json.Marshal(r)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditFilter 浏览审计日志时的筛选条件，零值表示不筛选
+type AuditFilter struct {
+	Action  string     `json:"action"`
+	EntryID string     `json:"entryId"`
+	Query   string     `json:"query"` // 在条目名和 Detail 中查找，不区分大小写
+	From    *time.Time `json:"from"`
+	To      *time.Time `json:"to"`
+	Limit   int        `json:"limit"` // 最多返回的条数，0 表示不限
+}
+
+// AuditStatus 校验审计日志的结果
+type AuditStatus struct {
+	Valid   bool   `json:"valid"`
+	Records int    `json:"records"`
+	Problem string `json:"problem"` // 第一个发现的问题，Valid 时为空
+}
+
+// auditHead 最后一条记录的序号和哈希，单独加密保存，用于发现日志末尾被截断
+type auditHead struct {
+	Seq  int    `json:"seq"`
+	Hash string `json:"hash"`
+}
+
+// AuditLog 只追加的审计日志。每行是一条单独用密码库密钥加密并 base64 编码的记录，
+// 每条记录带有上一条的哈希，修改或删除中间的记录会让链条断开；
+// 最后一条记录的序号和哈希另存在 .head 文件中，截断末尾的记录同样能被发现
+type AuditLog struct {
+	mu        sync.Mutex
+	path      string
+	vaultPath string // 密码库文件，写入时使用与它当前文件头匹配的密钥
+	loaded    bool   // seq 和 last 是否已从文件读取
+	seq       int
+	last      string
+}
+
+// NewAuditLog 密码库 vaultPath 的审计日志，保存在 vaultPath.audit
+func NewAuditLog(vaultPath string) *AuditLog {
+	return &AuditLog{path: vaultPath + ".audit", vaultPath: vaultPath}
+}
+
+func (l *AuditLog) headPath() string {
+	return l.path + ".head"
+}
+
+// Reset 丢弃缓存的最后一条记录，下次追加时重新从文件读取。解锁时调用
+func (l *AuditLog) Reset() {
+	l.mu.Lock()
+	l.loaded, l.seq, l.last = false, 0, ""
+	l.mu.Unlock()
+}
+
+// Append 追加一条记录，Seq、Prev 和 Hash 由日志填写，Time 为空时使用当前时间
+func (l *AuditLog) Append(keys KeyProvider, record AuditRecord) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.loaded {
+		if err := l.loadTail(keys); err != nil {
+			return err
+		}
+	}
+	if record.Time.IsZero() {
+		record.Time = time.Now()
+	}
+	record.Time = record.Time.UTC()
+	record.Seq = l.seq + 1
+	record.Prev = l.last
+	record.Hash = record.digest()
+
+	key, err := CurrentKey(l.vaultPath, keys)
+	if err != nil {
+		return err
+	}
+	line, err := sealAuditLine(key, record)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	l.seq, l.last = record.Seq, record.Hash
+
+	head, err := json.Marshal(auditHead{Seq: record.Seq, Hash: record.Hash})
+	if err != nil {
+		return err
+	}
+	sealed, err := SealVault(key, head)
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(l.headPath(), sealed, 0600)
+}
+
+// Seq 最后一条记录的序号，即日志中的记录数
+func (l *AuditLog) Seq(keys KeyProvider) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.loaded {
+		if err := l.loadTail(keys); err != nil {
+			return 0, err
+		}
+	}
+	return l.seq, nil
+}
+
+// loadTail 读取最后一条记录的序号和哈希，调用时需持有 l.mu
+func (l *AuditLog) loadTail(keys KeyProvider) error {
+	if err := l.finishRekey(); err != nil {
+		return err
+	}
+	lines, err := l.lines()
+	if err != nil {
+		return err
+	}
+	l.seq, l.last = 0, ""
+	if len(lines) > 0 {
+		record, err := openAuditLine(lines[len(lines)-1], keys)
+		if err != nil {
+			return err
+		}
+		l.seq, l.last = record.Seq, record.Hash
+	}
+	l.loaded = true
+	return nil
+}
+
+// lines 读取日志文件的所有非空行，文件不存在时返回空
+func (l *AuditLog) lines() ([][]byte, error) {
+	raw, err := os.ReadFile(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var lines [][]byte
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(raw)+1)
+	for scanner.Scan() {
+		if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+			lines = append(lines, bytes.Clone(line))
+		}
+	}
+	return lines, scanner.Err()
+}
+
+func sealAuditLine(key *VaultKey, record AuditRecord) ([]byte, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := SealVault(key, data)
+	if err != nil {
+		return nil, err
+	}
+	line := base64.StdEncoding.EncodeToString(sealed)
+	return []byte(line + "\n"), nil
+}
+
+func openAuditLine(line []byte, keys KeyProvider) (AuditRecord, error) {
+	var record AuditRecord
+	sealed, err := base64.StdEncoding.DecodeString(string(line))
+	if err != nil {
+		return record, err
+	}
+	_, _, plaintext, err := OpenVault(sealed, keys)
+	if err != nil {
+		return record, err
+	}
+	err = json.Unmarshal(plaintext, &record)
+	return record, err
+}
+
+// Records 解密并返回所有记录（从旧到新），任何一行无法解密时返回 ErrAuditTampered
+func (l *AuditLog) Records(keys KeyProvider) ([]AuditRecord, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.records(keys)
+}
+
+func (l *AuditLog) records(keys KeyProvider) ([]AuditRecord, error) {
+	if err := l.finishRekey(); err != nil {
+		return nil, err
+	}
+	lines, err := l.lines()
+	if err != nil {
+		return nil, err
+	}
+	records := make([]AuditRecord, 0, len(lines))
+	for i, line := range lines {
+		record, err := openAuditLine(line, keys)
+		if errors.Is(err, ErrVaultLocked) {
+			return nil, err
+		} else if err != nil && l.oldKeyLine(line) {
+			return nil, fmt.Errorf("%w: line %d is encrypted with a previous master password", ErrAuditTampered, i+1)
+		} else if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrAuditTampered, i+1, err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// oldKeyLine 无法解密的一行是否属于另一个 salt，即修改主密码时没有重新加密
+func (l *AuditLog) oldKeyLine(line []byte) bool {
+	sealed, err := base64.StdEncoding.DecodeString(string(line))
+	if err != nil {
+		return false
+	}
+	header, _, err := ParseVaultHeader(sealed)
+	if err != nil {
+		return false
+	}
+	current, err := ReadVaultHeader(l.vaultPath)
+	return err == nil && !bytes.Equal(header.Salt, current.Salt)
+}
+
+// Verify 检查每条记录的哈希、序号和链接，并与 .head 文件比较以发现末尾被截断。
+// minRecords 为密码库中记录的 Vault.AuditSeq，日志和 .head 被一起删除或替换为更短的旧版本时同样能发现
+func (l *AuditLog) Verify(keys KeyProvider, minRecords int) (AuditStatus, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	records, err := l.records(keys)
+	if errors.Is(err, ErrAuditTampered) {
+		return AuditStatus{Problem: err.Error()}, nil
+	} else if err != nil {
+		return AuditStatus{}, err
+	}
+	status := AuditStatus{Records: len(records)}
+
+	prev := ""
+	for i, r := range records {
+		switch {
+		case r.Seq != i+1:
+			status.Problem = fmt.Sprintf("record %d has sequence number %d", i+1, r.Seq)
+		case r.Prev != prev:
+			status.Problem = fmt.Sprintf("record %d does not link to the previous record", r.Seq)
+		case r.Hash != r.digest():
+			status.Problem = fmt.Sprintf("record %d has been edited", r.Seq)
+		}
+		if status.Problem != "" {
+			return status, nil
+		}
+		prev = r.Hash
+	}
+	if len(records) < minRecords {
+		status.Problem = fmt.Sprintf("log has been truncated or deleted: %d of at least %d records left", len(records), minRecords)
+		return status, nil
+	}
+
+	raw, err := os.ReadFile(l.headPath())
+	if os.IsNotExist(err) {
+		if len(records) > 0 {
+			status.Problem = "head file is missing"
+			return status, nil
+		}
+		status.Valid = true
+		return status, nil
+	} else if err != nil {
+		return status, err
+	}
+	var head auditHead
+	_, _, plaintext, err := OpenVault(raw, keys)
+	if err == nil {
+		err = json.Unmarshal(plaintext, &head)
+	}
+	switch {
+	case errors.Is(err, ErrVaultLocked):
+		return status, err
+	case err != nil:
+		status.Problem = fmt.Sprintf("head file cannot be read: %v", err)
+	case head.Seq > len(records):
+		status.Problem = fmt.Sprintf("log has been truncated: %d of %d records left", len(records), head.Seq)
+	case head.Seq != len(records) || head.Hash != prev:
+		status.Problem = "last record does not match the head file"
+	default:
+		status.Valid = true
+	}
+	return status, nil
+}
+
+// PrepareRekey 修改主密码时用 newKey 重新加密所有记录和 .head 文件，记录内容和哈希不变，链条保持有效。
+// 新的日志和 .head 只写入临时文件，要等密码库本身换成 newKey 之后由 FinishRekey 替换，
+// 因此日志和密码库总是使用同一个密钥
+func (l *AuditLog) PrepareRekey(oldKeys KeyProvider, newKey *VaultKey) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	records, err := l.records(oldKeys)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	for _, r := range records {
+		line, err := sealAuditLine(newKey, r)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+	}
+	last := records[len(records)-1]
+	head, err := json.Marshal(auditHead{Seq: last.Seq, Hash: last.Hash})
+	if err != nil {
+		return err
+	}
+	sealed, err := SealVault(newKey, head)
+	if err != nil {
+		return err
+	}
+	logTmp, headTmp := l.rekeyPaths()
+	if err := WriteFileAtomic(logTmp, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	if err := WriteFileAtomic(headTmp, sealed, 0600); err != nil {
+		os.Remove(logTmp)
+		return err
+	}
+	return nil
+}
+
+// FinishRekey 在密码库重新加密（或放弃重新加密）之后调用，替换或丢弃 PrepareRekey 写好的临时文件
+func (l *AuditLog) FinishRekey() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.loaded = false
+	return l.finishRekey()
+}
+
+func (l *AuditLog) rekeyPaths() (string, string) {
+	return l.path + ".rekey", l.headPath() + ".rekey"
+}
+
+// finishRekey 处理 PrepareRekey 留下的临时文件，调用时需持有 l.mu。
+// 临时的 .head 最后写入，它存在说明两个临时文件都已写完整；它的 salt 与密码库当前的文件头相同时，
+// 说明密码库已经换成了新密钥，用临时文件替换日志，否则（密码库没有替换或临时文件不完整）丢弃它们。
+// 中途崩溃后下一次读取日志时同样按这个规则处理
+func (l *AuditLog) finishRekey() error {
+	logTmp, headTmp := l.rekeyPaths()
+	raw, err := os.ReadFile(headTmp)
+	if os.IsNotExist(err) {
+		return removeIfExists(logTmp)
+	} else if err != nil {
+		return err
+	}
+	current, err := ReadVaultHeader(l.vaultPath)
+	if err != nil {
+		return err
+	}
+	header, _, err := ParseVaultHeader(raw)
+	if err != nil || !bytes.Equal(header.Salt, current.Salt) {
+		return errors.Join(removeIfExists(logTmp), removeIfExists(headTmp))
+	}
+	if err := RenameDurable(logTmp, l.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return RenameDurable(headTmp, l.headPath())
+}
+
+// removeIfExists 删除 path，文件不存在时不算错误
+func removeIfExists(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// FilterAudit 按 filter 筛选记录，结果按时间从新到旧
+func FilterAudit(records []AuditRecord, filter AuditFilter) []AuditRecord {
+	query := strings.ToLower(strings.TrimSpace(filter.Query))
+	result := []AuditRecord{}
+	for i := len(records) - 1; i >= 0; i-- {
+		r := records[i]
+		if filter.Action != "" && r.Action != filter.Action {
+			continue
+		}
+		if filter.EntryID != "" && r.EntryID != filter.EntryID {
+			continue
+		}
+		if filter.From != nil && r.Time.Before(*filter.From) {
+			continue
+		}
+		if filter.To != nil && r.Time.After(*filter.To) {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(r.Name), query) &&
+			!strings.Contains(strings.ToLower(r.Detail), query) {
+			continue
+		}
+		result = append(result, r)
+		if filter.Limit > 0 && len(result) >= filter.Limit {
+			break
+		}
+	}
+	return result
+}
+
+// entriesByID 目录树和回收站中的所有条目
+func (v *Vault) entriesByID() map[string]*Entry {
+	entries := make(map[string]*Entry)
+	var walk func(node Node)
+	walk = func(node Node) {
+		switch n := node.(type) {
+		case *Entry:
+			entries[n.ID] = n
+		case *Folder:
+			for _, child := range n.Children {
+				walk(child)
+			}
+		}
+	}
+	walk(v.Root)
+	for _, item := range v.Trash {
+		walk(item.Node)
+	}
+	return entries
+}
+
+// AuditChanges 比较修改前后的密码库，为新建、修改和彻底删除的条目各生成一条 AuditEdit 记录，
+// Detail 为操作名 op。只移动或放入回收站的条目不算修改
+func AuditChanges(op string, before *Vault, after *Vault) []AuditRecord {
+	old, current := before.entriesByID(), after.entriesByID()
+	var records []AuditRecord
+	for id, e := range current {
+		if prev, ok := old[id]; !ok || !prev.Modified.Equal(e.Modified) {
+			records = append(records, AuditRecord{Action: AuditEdit, EntryID: id, Name: e.Name, Detail: op})
+		}
+	}
+	for id, e := range old {
+		if _, ok := current[id]; !ok {
+			records = append(records, AuditRecord{Action: AuditEdit, EntryID: id, Name: e.Name, Detail: op + " (removed)"})
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Name < records[j].Name
+	})
+	return records
+}
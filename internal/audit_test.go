@@ -0,0 +1,146 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// headerOnlyKeys 像密钥环一样只能按文件头取得密钥，header 为 nil 时失败
+type headerOnlyKeys struct {
+	KeyProvider
+}
+
+func (k headerOnlyKeys) ObtainKey(header *VaultHeader) (*VaultKey, error) {
+	if header == nil {
+		return nil, errors.New("no header")
+	}
+	return k.KeyProvider.ObtainKey(header)
+}
+
+// testAuditLog 用 testKeyA 新建密码库，返回它的审计日志
+func testAuditLog(t *testing.T) (*AuditLog, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "resource.json")
+	if err := SaveContent(path, NewMemoryKeyProvider(testKeyA), NewVault()); err != nil {
+		t.Fatal(err)
+	}
+	return NewAuditLog(path), path
+}
+
+func appendAudit(t *testing.T, log *AuditLog, keys KeyProvider, details ...string) {
+	t.Helper()
+	for _, d := range details {
+		if err := log.Append(keys, AuditRecord{Action: AuditCopy, Detail: d}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func assertAuditValid(t *testing.T, log *AuditLog, keys KeyProvider, records int) {
+	t.Helper()
+	status, err := log.Verify(keys, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Valid || status.Records != records {
+		t.Errorf("status = %+v, want %d valid records", status, records)
+	}
+}
+
+func TestAuditAppendUsesVaultHeader(t *testing.T) {
+	log, _ := testAuditLog(t)
+	keys := headerOnlyKeys{NewMemoryKeyProvider(testKeyA)}
+	appendAudit(t, log, keys, "a", "b")
+	log.Reset()
+	appendAudit(t, log, keys, "c")
+	assertAuditValid(t, log, keys, 3)
+}
+
+func TestAuditRekey(t *testing.T) {
+	oldKeys := NewMemoryKeyProvider(testKeyA)
+	newKey := &VaultKey{Key: testKeyB}
+	var err error
+	if newKey.Header, err = NewVaultHeader(testKDF); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("vault not replaced", func(t *testing.T) {
+		log, _ := testAuditLog(t)
+		appendAudit(t, log, oldKeys, "a", "b")
+		if err := log.PrepareRekey(oldKeys, newKey); err != nil {
+			t.Fatal(err)
+		}
+		// 崩溃后重新打开：密码库仍是旧密钥，临时文件被丢弃
+		log.Reset()
+		appendAudit(t, log, oldKeys, "c")
+		assertAuditValid(t, log, oldKeys, 3)
+		assertOnlyFiles(t, filepath.Dir(log.path), "resource.json", "resource.json.audit", "resource.json.audit.head")
+	})
+
+	t.Run("vault replaced", func(t *testing.T) {
+		log, path := testAuditLog(t)
+		appendAudit(t, log, oldKeys, "a", "b")
+		if err := log.PrepareRekey(oldKeys, newKey); err != nil {
+			t.Fatal(err)
+		}
+		data, err := json.Marshal(NewVault())
+		if err != nil {
+			t.Fatal(err)
+		}
+		sealed, err := SealVault(newKey, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := WriteFileAtomic(path, sealed, 0600); err != nil {
+			t.Fatal(err)
+		}
+		// 崩溃后重新打开：密码库已是新密钥，读取日志时完成替换
+		newKeys := NewMemoryKeyProvider(testKeyB)
+		log.Reset()
+		appendAudit(t, log, newKeys, "c")
+		assertAuditValid(t, log, newKeys, 3)
+		assertOnlyFiles(t, filepath.Dir(path), "resource.json", "resource.json.audit", "resource.json.audit.head")
+	})
+}
+
+func TestAuditMixedKeys(t *testing.T) {
+	log, path := testAuditLog(t)
+	appendAudit(t, log, NewMemoryKeyProvider(testKeyA), "a")
+	// 密码库换了密钥而日志没有重新加密
+	if err := RekeyFile(path, NewMemoryKeyProvider(testKeyA), NewMemoryKeyProvider(testKeyB)); err != nil {
+		t.Fatal(err)
+	}
+	status, err := log.Verify(NewMemoryKeyProvider(testKeyB), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Valid || !strings.Contains(status.Problem, "previous master password") {
+		t.Errorf("status = %+v, want a previous master password problem", status)
+	}
+}
+
+func TestAuditVerifyDeletedLog(t *testing.T) {
+	log, _ := testAuditLog(t)
+	keys := NewMemoryKeyProvider(testKeyA)
+	appendAudit(t, log, keys, "a", "b")
+	seq, err := log.Seq(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{log.path, log.headPath()} {
+		if err := os.Remove(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	status, err := log.Verify(keys, seq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Valid {
+		t.Errorf("deleting the log and its head should be detected, got %+v", status)
+	}
+}
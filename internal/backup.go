@@ -0,0 +1,230 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	backupPrefix     = "resource-"
+	backupSuffix     = ".bak"
+	backupTimeLayout = "20060102-150405.000"
+)
+
+var ErrBackupNotFound = errors.New("backup not found")
+
+// BackupInfo 一个备份文件的信息，ID 即文件名中的时间戳，同一毫秒内的多个备份带 -序号 后缀
+type BackupInfo struct {
+	ID   string    `json:"id"`
+	Time time.Time `json:"time"`
+	Size int64     `json:"size"`
+}
+
+// BackupManager 管理密码库的滚动备份。备份是保存前旧文件的原样拷贝，
+// 因此同样是加密的，恢复时无需解密
+type BackupManager struct {
+	Dir    string
+	Policy func() BackupConfig // 每次清理时读取，设置页修改后立即生效
+}
+
+func NewBackupManager(dir string, policy func() BackupConfig) *BackupManager {
+	os.MkdirAll(dir, 0755)
+	return &BackupManager{
+		Dir:    dir,
+		Policy: policy,
+	}
+}
+
+// Backup 把 vaultPath 当前的内容复制为一个新的备份，然后按保留策略清理旧备份。
+// vaultPath 不存在时什么也不做
+func (m *BackupManager) Backup(vaultPath string) error {
+	data, err := os.ReadFile(vaultPath)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	id, err := m.newID(time.Now())
+	if err != nil {
+		return err
+	}
+	if err := WriteFileAtomic(m.path(id), data, 0644); err != nil {
+		return err
+	}
+	return m.Prune()
+}
+
+// newID 返回 t 对应的、尚未被占用的备份 ID，避免同一毫秒内的两次保存互相覆盖
+func (m *BackupManager) newID(t time.Time) (string, error) {
+	base := t.Format(backupTimeLayout)
+	id := base
+	for seq := 1; ; seq++ {
+		if _, err := os.Stat(m.path(id)); os.IsNotExist(err) {
+			return id, nil
+		} else if err != nil {
+			return "", err
+		}
+		id = fmt.Sprintf("%s-%d", base, seq)
+	}
+}
+
+// parseBackupID 解析备份 ID 中的时间和序号
+func parseBackupID(id string) (time.Time, int, error) {
+	stamp, seq := id, 0
+	if len(id) > len(backupTimeLayout) && id[len(backupTimeLayout)] == '-' {
+		n, err := strconv.Atoi(id[len(backupTimeLayout)+1:])
+		if err != nil || n < 1 {
+			return time.Time{}, 0, ErrBackupNotFound
+		}
+		stamp, seq = id[:len(backupTimeLayout)], n
+	}
+	t, err := time.ParseInLocation(backupTimeLayout, stamp, time.Local)
+	if err != nil {
+		return time.Time{}, 0, ErrBackupNotFound
+	}
+	return t, seq, nil
+}
+
+func (m *BackupManager) path(id string) string {
+	return filepath.Join(m.Dir, backupPrefix+id+backupSuffix)
+}
+
+// Path 返回备份文件路径，id 必须是 List 返回过的合法时间戳
+func (m *BackupManager) Path(id string) (string, error) {
+	if _, _, err := parseBackupID(id); err != nil {
+		return "", err
+	}
+	p := m.path(id)
+	if _, err := os.Stat(p); err != nil {
+		return "", ErrBackupNotFound
+	}
+	return p, nil
+}
+
+// List 按时间从新到旧列出所有备份
+func (m *BackupManager) List() ([]BackupInfo, error) {
+	entries, err := os.ReadDir(m.Dir)
+	if os.IsNotExist(err) {
+		return []BackupInfo{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	backups := make([]BackupInfo, 0, len(entries))
+	seqs := make(map[string]int)
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, backupPrefix) || !strings.HasSuffix(name, backupSuffix) {
+			continue
+		}
+		id := strings.TrimSuffix(strings.TrimPrefix(name, backupPrefix), backupSuffix)
+		t, seq, err := parseBackupID(id)
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		seqs[id] = seq
+		backups = append(backups, BackupInfo{ID: id, Time: t, Size: info.Size()})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		if backups[i].Time.Equal(backups[j].Time) {
+			return seqs[backups[i].ID] > seqs[backups[j].ID]
+		}
+		return backups[i].Time.After(backups[j].Time)
+	})
+	return backups, nil
+}
+
+// Prune 按保留策略删除多余的备份：
+// 最近 KeepLast 份全部保留；最近 KeepDaily 天每天保留最新一份；最近 KeepWeekly 周每周保留最新一份
+func (m *BackupManager) Prune() error {
+	backups, err := m.List()
+	if err != nil {
+		return err
+	}
+	policy := m.Policy()
+	now := time.Now()
+
+	keep := make(map[string]bool)
+	days := make(map[string]bool)
+	weeks := make(map[string]bool)
+	for i, b := range backups {
+		if i < policy.KeepLast {
+			keep[b.ID] = true
+		}
+
+		day := b.Time.Format("2006-01-02")
+		if !days[day] && now.Sub(b.Time) < time.Duration(policy.KeepDaily)*24*time.Hour {
+			days[day] = true
+			keep[b.ID] = true
+		}
+
+		year, week := b.Time.ISOWeek()
+		weekKey := fmt.Sprintf("%d-%d", year, week)
+		if !weeks[weekKey] && now.Sub(b.Time) < time.Duration(policy.KeepWeekly)*7*24*time.Hour {
+			weeks[weekKey] = true
+			keep[b.ID] = true
+		}
+	}
+
+	var errs []error
+	for _, b := range backups {
+		if !keep[b.ID] {
+			if err := os.Remove(m.path(b.ID)); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Read 用 keys 解密备份内容，用于预览或恢复前校验。只读打开，不会升级备份文件或改变 keys 缓存的密钥
+func (m *BackupManager) Read(id string, keys KeyProvider) (*Vault, error) {
+	p, err := m.Path(id)
+	if err != nil {
+		return nil, err
+	}
+	return PeekContent(p, keys)
+}
+
+// Restore 用备份原样覆盖 vaultPath。覆盖前会先把当前文件也做一次备份，恢复操作本身可以撤销
+func (m *BackupManager) Restore(id string, vaultPath string) error {
+	p, err := m.Path(id)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return err
+	}
+	if err := m.Backup(vaultPath); err != nil {
+		return err
+	}
+	return WriteFileAtomic(vaultPath, data, 0644)
+}
+
+// Rekey 修改主密码后用 newKeys 重新加密所有备份，每个备份都按 RekeyFile 的方式崩溃安全地替换。
+// 单个备份失败不影响其它备份，所有错误合并返回
+func (m *BackupManager) Rekey(oldKeys KeyProvider, newKeys KeyProvider) error {
+	backups, err := m.List()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, b := range backups {
+		if err := RekeyFile(m.path(b.ID), oldKeys, newKeys); err != nil {
+			errs = append(errs, fmt.Errorf("backup %s: %w", b.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -19,10 +19,72 @@ type AppearanceConfig struct {
 	Opacity uint8 `json:"opacity"`
 }
 
+// SecurityConfig 密码库锁定相关设置
+type SecurityConfig struct {
+	AutoLockMinutes int  `json:"autoLockMinutes"` // 空闲多少分钟后自动锁定，0 表示不自动锁定
+	LockOnHide      bool `json:"lockOnHide"`      // 隐藏窗口时立即锁定
+	RememberKey     bool `json:"rememberKey"`     // 把派生好的密钥保存在系统密钥环中，无需主密码即可解锁（仅 Linux）
+	// 健康报告中超过多少天没有修改的密码列为过旧，0 表示不检查
+	OldSecretDays int `json:"oldSecretDays"`
+}
+
+// BackupConfig 滚动备份的保留策略，三条规则保留的备份取并集
+type BackupConfig struct {
+	KeepLast   int `json:"keepLast"`   // 最近的若干个版本全部保留
+	KeepDaily  int `json:"keepDaily"`  // 最近若干天每天保留最新一份
+	KeepWeekly int `json:"keepWeekly"` // 最近若干周每周保留最新一份
+}
+
+// TrashConfig 回收站设置
+type TrashConfig struct {
+	PurgeDays int `json:"purgeDays"` // 删除超过多少天后自动永久删除，0 表示不自动清理
+}
+
+// HistoryConfig 撤销/重做历史设置
+type HistoryConfig struct {
+	Limit   int  `json:"limit"`   // 最多可撤销的步数
+	Persist bool `json:"persist"` // 加密保存到磁盘，锁定或重启后仍可撤销
+	// 每个条目保留的字段旧值个数，0 表示不保留
+	FieldVersions int `json:"fieldVersions"`
+}
+
+// AttachmentConfig 附件大小限制，0 表示不限制
+type AttachmentConfig struct {
+	MaxFileKB  int `json:"maxFileKB"`  // 单个附件
+	MaxTotalKB int `json:"maxTotalKB"` // 整个密码库的附件总和
+}
+
+// SSHAgentConfig 内置 ssh-agent 设置
+type SSHAgentConfig struct {
+	Enabled bool   `json:"enabled"`
+	Path    string `json:"path"` // Unix socket 或命名管道路径，为空时使用 DefaultSSHAgentPath
+}
+
+// LogConfig 日志设置，日志文件在 DefaultLogDir 下
+type LogConfig struct {
+	Level     string `json:"level"`     // debug、info、warn 或 error
+	MaxSizeMB int    `json:"maxSizeMB"` // 单个日志文件超过该大小后滚动
+	MaxFiles  int    `json:"maxFiles"`  // 保留的旧日志文件个数
+}
+
+// ExpiryConfig 过期提醒设置
+type ExpiryConfig struct {
+	WarnDays int `json:"warnDays"` // 提前多少天提醒
+}
+
 type Config struct {
-	General    GeneralConfig    `json:"general"`
-	Shortcuts  ShortcutsConfig  `json:"shortcuts"`
-	Appearance AppearanceConfig `json:"appearance"`
+	General     GeneralConfig    `json:"general"`
+	Shortcuts   ShortcutsConfig  `json:"shortcuts"`
+	Appearance  AppearanceConfig `json:"appearance"`
+	Security    SecurityConfig   `json:"security"`
+	Backup      BackupConfig     `json:"backup"`
+	Trash       TrashConfig      `json:"trash"`
+	History     HistoryConfig    `json:"history"`
+	Attachments AttachmentConfig `json:"attachments"`
+	Expiry      ExpiryConfig     `json:"expiry"`
+	Generator   GeneratorPolicy  `json:"generator"` // 文件夹没有设置规则时使用
+	SSHAgent    SSHAgentConfig   `json:"sshAgent"`
+	Log         LogConfig        `json:"log"`
 }
 
 // Config 定义你的配置项
@@ -43,28 +105,69 @@ func NewConfigManager() *ConfigManager {
 	}
 }
 
+// DefaultConfig 默认配置
+func DefaultConfig() *Config {
+	return &Config{
+		GeneralConfig{
+			LaunchAtLogin: false,
+		},
+		ShortcutsConfig{
+			WakeUp:        [2]string{"Alt", "Space"},
+			PasteWaitTime: 100,
+		},
+		AppearanceConfig{
+			Opacity: 250,
+		},
+		SecurityConfig{
+			AutoLockMinutes: 5,
+			LockOnHide:      false,
+			OldSecretDays:   365,
+		},
+		BackupConfig{
+			KeepLast:   20,
+			KeepDaily:  7,
+			KeepWeekly: 4,
+		},
+		TrashConfig{
+			PurgeDays: 30,
+		},
+		HistoryConfig{
+			Limit:         100,
+			Persist:       false,
+			FieldVersions: 10,
+		},
+		AttachmentConfig{
+			MaxFileKB:  1024,
+			MaxTotalKB: 16 * 1024,
+		},
+		ExpiryConfig{
+			WarnDays: 7,
+		},
+		DefaultGeneratorPolicy(),
+		SSHAgentConfig{
+			Enabled: false,
+			Path:    "",
+		},
+		LogConfig{
+			Level:     "info",
+			MaxSizeMB: 5,
+			MaxFiles:  3,
+		},
+	}
+}
+
 // Load 读取配置
 func (m *ConfigManager) Load() (*Config, error) {
 	data, err := os.ReadFile(m.Path)
 	if err != nil {
 		// 如果文件不存在，返回默认配置
-		return &Config{
-			GeneralConfig{
-				LaunchAtLogin: false,
-			},
-			ShortcutsConfig{
-				WakeUp:        [2]string{"Alt", "Space"},
-				PasteWaitTime: 100,
-			},
-			AppearanceConfig{
-				Opacity: 250,
-			},
-		}, nil
+		return DefaultConfig(), nil
 	}
 
-	var config Config
-	err = json.Unmarshal(data, &config)
-	return &config, err
+	// 在默认配置上覆盖，旧版本配置文件中缺少的新字段保持默认值
+	config := DefaultConfig()
+	err = json.Unmarshal(data, config)
+	return config, err
 }
 
 // Save 保存配置
@@ -0,0 +1,268 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// vaultMagic 密码库文件头的魔数
+var vaultMagic = []byte("QCLV")
+
+const (
+	vaultVersion1 = 1 // Argon2id + AES-256-CBC，没有认证，只读
+	vaultVersion2 = 2 // Argon2id + AES-256-GCM，整个文件头作为附加数据
+	vaultVersion3 = 3 // 在 v2 基础上增加 flags，记录是否需要密钥文件
+	vaultVersion  = vaultVersion3
+
+	kdfArgon2id     = 1
+	cipherAES256GCM = 1
+
+	saltSize = 16
+
+	flagKeyFile = 1 << 0
+
+	maxKDFMemory = 4 * 1024 * 1024 // KiB，即 4 GiB
+	maxKDFTime   = 1000
+)
+
+var (
+	ErrNoVaultHeader   = errors.New("vault header not found")
+	ErrHeaderTruncated = errors.New("vault header truncated")
+	ErrInvalidKDF      = errors.New("invalid kdf parameters")
+)
+
+// VaultHeader 写在密码库文件开头的明文头部。
+// v2 布局: magic | version | kdf | time | memory | threads | saltLen | salt | cipher | nonceLen | nonce
+// v3 在末尾追加: flags | [checkLen | keyFileCheck]
+type VaultHeader struct {
+	Version      uint8
+	KDF          KDFParams
+	Salt         []byte
+	Nonce        []byte
+	KeyFile      bool   // 是否需要密钥文件
+	KeyFileCheck []byte // 密钥文件校验值，见 keyFileCheck
+}
+
+// NewVaultHeader 使用随机 salt 和给定参数创建文件头，nonce 在每次加密时生成
+func NewVaultHeader(params KDFParams) (*VaultHeader, error) {
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	return &VaultHeader{
+		Version: vaultVersion,
+		KDF:     params,
+		Salt:    salt,
+	}, nil
+}
+
+// MarshalBinary 按 Version 对应的布局编码文件头
+func (h *VaultHeader) MarshalBinary() ([]byte, error) {
+	if len(h.Salt) > 255 || len(h.Nonce) > 255 || len(h.KeyFileCheck) > 255 {
+		return nil, errors.New("header field too long")
+	}
+	buf := new(bytes.Buffer)
+	buf.Write(vaultMagic)
+	buf.WriteByte(h.Version)
+	if h.Version >= vaultVersion2 {
+		buf.WriteByte(kdfArgon2id)
+	}
+	binary.Write(buf, binary.BigEndian, h.KDF.Time)
+	binary.Write(buf, binary.BigEndian, h.KDF.Memory)
+	buf.WriteByte(h.KDF.Threads)
+	buf.WriteByte(byte(len(h.Salt)))
+	buf.Write(h.Salt)
+	if h.Version >= vaultVersion2 {
+		buf.WriteByte(cipherAES256GCM)
+		buf.WriteByte(byte(len(h.Nonce)))
+		buf.Write(h.Nonce)
+	}
+	if h.Version >= vaultVersion3 {
+		var flags byte
+		if h.KeyFile {
+			flags |= flagKeyFile
+		}
+		buf.WriteByte(flags)
+		if h.KeyFile {
+			buf.WriteByte(byte(len(h.KeyFileCheck)))
+			buf.Write(h.KeyFileCheck)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// ParseVaultHeader 解析文件头，返回文件头及其在 data 中占用的字节数。
+// 没有魔数时返回 ErrNoVaultHeader，说明是最早的无头 CBC 文件
+func ParseVaultHeader(data []byte) (*VaultHeader, int, error) {
+	if !bytes.HasPrefix(data, vaultMagic) {
+		return nil, 0, ErrNoVaultHeader
+	}
+	r := bytes.NewReader(data[len(vaultMagic):])
+
+	h := &VaultHeader{}
+	if err := binary.Read(r, binary.BigEndian, &h.Version); err != nil {
+		return nil, 0, ErrHeaderTruncated
+	}
+	if h.Version < vaultVersion1 || h.Version > vaultVersion3 {
+		return nil, 0, fmt.Errorf("unsupported vault version %d", h.Version)
+	}
+	if h.Version >= vaultVersion2 {
+		kdf, err := r.ReadByte()
+		if err != nil {
+			return nil, 0, ErrHeaderTruncated
+		}
+		if kdf != kdfArgon2id {
+			return nil, 0, fmt.Errorf("unsupported kdf %d", kdf)
+		}
+	}
+	if err := binary.Read(r, binary.BigEndian, &h.KDF.Time); err != nil {
+		return nil, 0, ErrHeaderTruncated
+	}
+	if err := binary.Read(r, binary.BigEndian, &h.KDF.Memory); err != nil {
+		return nil, 0, ErrHeaderTruncated
+	}
+	if err := binary.Read(r, binary.BigEndian, &h.KDF.Threads); err != nil {
+		return nil, 0, ErrHeaderTruncated
+	}
+	if err := h.KDF.validate(); err != nil {
+		return nil, 0, err
+	}
+	salt, err := readShortBytes(r)
+	if err != nil {
+		return nil, 0, ErrHeaderTruncated
+	}
+	h.Salt = salt
+	if h.Version >= vaultVersion2 {
+		c, err := r.ReadByte()
+		if err != nil {
+			return nil, 0, ErrHeaderTruncated
+		}
+		if c != cipherAES256GCM {
+			return nil, 0, fmt.Errorf("unsupported cipher %d", c)
+		}
+		if h.Nonce, err = readShortBytes(r); err != nil {
+			return nil, 0, ErrHeaderTruncated
+		}
+	}
+	if h.Version >= vaultVersion3 {
+		flags, err := r.ReadByte()
+		if err != nil {
+			return nil, 0, ErrHeaderTruncated
+		}
+		h.KeyFile = flags&flagKeyFile != 0
+		if h.KeyFile {
+			if h.KeyFileCheck, err = readShortBytes(r); err != nil {
+				return nil, 0, ErrHeaderTruncated
+			}
+		}
+	}
+	return h, len(data) - r.Len(), nil
+}
+
+// validate 检查从文件头读出的 KDF 参数，损坏的参数会让 argon2 panic 或耗尽内存
+func (p KDFParams) validate() error {
+	switch {
+	case p.Time < 1 || p.Time > maxKDFTime:
+		return fmt.Errorf("%w: time %d", ErrInvalidKDF, p.Time)
+	case p.Threads < 1:
+		return fmt.Errorf("%w: threads %d", ErrInvalidKDF, p.Threads)
+	case p.Memory < 8*uint32(p.Threads) || p.Memory > maxKDFMemory:
+		return fmt.Errorf("%w: memory %d KiB", ErrInvalidKDF, p.Memory)
+	}
+	return nil
+}
+
+// readShortBytes 读取一个字节长度前缀的字段
+func readShortBytes(r *bytes.Reader) ([]byte, error) {
+	n, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// SealVault 用当前格式加密 plaintext，返回完整的文件内容。
+// 每次都会生成新的 nonce，文件头整体作为 GCM 的附加数据参与认证
+func SealVault(key *VaultKey, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := *key.Header
+	header.Version = vaultVersion
+	header.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, header.Nonce); err != nil {
+		return nil, err
+	}
+
+	aad, err := header.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Seal(aad, header.Nonce, plaintext, aad), nil
+}
+
+// OpenVault 用 keys 提供的密钥解密文件内容，返回所用的密钥、文件头和明文
+func OpenVault(data []byte, keys KeyProvider) (*VaultKey, *VaultHeader, []byte, error) {
+	header, n, err := ParseVaultHeader(data)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	key, err := keys.ObtainKey(header)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	plaintext, err := openPayload(header, data[:n], data[n:], key.Key)
+	if err != nil {
+		return nil, nil, nil, ErrWrongPassword
+	}
+	return key, header, plaintext, nil
+}
+
+// ReadVaultHeader 只读取 path 处密码库的文件头
+func ReadVaultHeader(path string) (*VaultHeader, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	header, _, err := ParseVaultHeader(raw)
+	return header, err
+}
+
+// openPayload 按文件头的版本选择解密方式
+func openPayload(header *VaultHeader, aad []byte, ciphertext []byte, key string) ([]byte, error) {
+	if header.Version == vaultVersion1 {
+		return DecryptBytes(ciphertext, key)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(header.Nonce) != gcm.NonceSize() {
+		return nil, errors.New("invalid nonce size")
+	}
+	return gcm.Open(nil, header.Nonce, ciphertext, aad)
+}
+
+func newGCM(key string) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, errors.New("key must be 32 bytes")
+	}
+	block, err := aes.NewCipher([]byte(key))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,349 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	testMasterPassword = "container-test-password"
+	testKeyA           = "aaaabbbbccccddddaaaabbbbccccdddd"
+	testKeyB           = "eeeeffffgggghhhheeeeffffgggghhhh"
+)
+
+// testKDF 测试中使用的低代价参数，避免每次派生都占用 64 MiB
+var testKDF = KDFParams{Time: 1, Memory: 64, Threads: 1}
+
+// testVaultKey 用 testKDF 和 testMasterPassword 派生密钥
+func testVaultKey(t *testing.T) *VaultKey {
+	t.Helper()
+	header, err := NewVaultHeader(testKDF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := NewVaultKey(Credentials{Password: testMasterPassword}, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// sealVersion 按旧版本的布局加密 plaintext，v3 即 SealVault
+func sealVersion(t *testing.T, version uint8, key *VaultKey, plaintext []byte) []byte {
+	t.Helper()
+	if version == vaultVersion3 {
+		sealed, err := SealVault(key, plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sealed
+	}
+	header := *key.Header
+	header.Version = version
+	if version == vaultVersion1 {
+		aad, err := header.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ciphertext, err := EncryptBytes(plaintext, key.Key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return append(aad, ciphertext...)
+	}
+	gcm, err := newGCM(key.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header.Nonce = bytes.Repeat([]byte{7}, gcm.NonceSize())
+	aad, err := header.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gcm.Seal(aad, header.Nonce, plaintext, aad)
+}
+
+func testVaultJSON(t *testing.T) []byte {
+	t.Helper()
+	vault, _ := testVault()
+	data, err := json.Marshal(vault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestOpenVaultVersions(t *testing.T) {
+	key := testVaultKey(t)
+	plaintext := testVaultJSON(t)
+	for _, version := range []uint8{vaultVersion1, vaultVersion2, vaultVersion3} {
+		sealed := sealVersion(t, version, key, plaintext)
+		_, header, got, err := OpenVault(sealed, NewPasswordKeyProvider(testMasterPassword))
+		if err != nil {
+			t.Fatalf("v%d: %v", version, err)
+		}
+		if header.Version != version || header.KDF != testKDF {
+			t.Errorf("v%d: header = %+v", version, header)
+		}
+		if !bytes.Equal(got, plaintext) {
+			t.Errorf("v%d: plaintext mismatch", version)
+		}
+		// v1 没有认证，错误的密码偶尔能通过填充检查，要在解析内容时才能发现
+		path := filepath.Join(t.TempDir(), "resource.json")
+		if err := os.WriteFile(path, sealed, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := PeekContent(path, NewPasswordKeyProvider("wrong")); !errors.Is(err, ErrWrongPassword) {
+			t.Errorf("v%d: wrong password: got %v", version, err)
+		}
+	}
+}
+
+func TestOpenVaultTamper(t *testing.T) {
+	key := testVaultKey(t)
+	sealed := sealVersion(t, vaultVersion3, key, testVaultJSON(t))
+	_, n, err := ParseVaultHeader(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saltAt := len(vaultMagic) + 1 + 1 + 4 + 4 + 1 + 1 // magic | version | kdf | time | memory | threads | saltLen
+
+	tests := []struct {
+		name   string
+		tamper func(data []byte) []byte
+		want   error
+	}{
+		{"gcm tag", func(d []byte) []byte { d[len(d)-1] ^= 1; return d }, ErrWrongPassword},
+		{"ciphertext", func(d []byte) []byte { d[n] ^= 1; return d }, ErrWrongPassword},
+		{"header salt", func(d []byte) []byte { d[saltAt] ^= 1; return d }, ErrWrongPassword},
+		{"header flags", func(d []byte) []byte { d[n-1] ^= 2; return d }, ErrWrongPassword},
+		{"truncated header", func(d []byte) []byte { return d[:saltAt] }, ErrHeaderTruncated},
+		{"no magic", func(d []byte) []byte { d[0] = 'X'; return d }, ErrNoVaultHeader},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tt.tamper(bytes.Clone(sealed))
+			// 内存中的密钥不依赖文件头，只有 GCM 的附加数据能发现文件头被修改
+			_, _, _, err := OpenVault(data, NewMemoryKeyProvider(key.Key))
+			if !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseVaultHeaderKDF(t *testing.T) {
+	key := testVaultKey(t)
+	sealed := sealVersion(t, vaultVersion3, key, testVaultJSON(t))
+	const timeAt, memoryAt, threadsAt = 6, 10, 14
+
+	tests := []struct {
+		name   string
+		tamper func(d []byte)
+	}{
+		{"zero time", func(d []byte) { binary.BigEndian.PutUint32(d[timeAt:], 0) }},
+		{"huge time", func(d []byte) { binary.BigEndian.PutUint32(d[timeAt:], 1<<24) }},
+		{"zero threads", func(d []byte) { d[threadsAt] = 0 }},
+		{"zero memory", func(d []byte) { binary.BigEndian.PutUint32(d[memoryAt:], 0) }},
+		{"huge memory", func(d []byte) { binary.BigEndian.PutUint32(d[memoryAt:], 1<<31) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := bytes.Clone(sealed)
+			tt.tamper(data)
+			if _, _, err := ParseVaultHeader(data); !errors.Is(err, ErrInvalidKDF) {
+				t.Fatalf("ParseVaultHeader: got %v, want ErrInvalidKDF", err)
+			}
+			// 解锁时归类为文件损坏，进入隔离流程而不是让 argon2 panic
+			path := filepath.Join(t.TempDir(), "resource.json")
+			if err := os.WriteFile(path, data, 0600); err != nil {
+				t.Fatal(err)
+			}
+			_, err := UnlockVault(path, NewPasswordKeyProvider(testMasterPassword))
+			if !IsVaultCorrupt(err) {
+				t.Errorf("UnlockVault: got %v, want a corrupt error", err)
+			}
+		})
+	}
+}
+
+func TestUnlockVaultUpgrades(t *testing.T) {
+	plaintext := testVaultJSON(t)
+	legacy, err := EncryptBytes([]byte(`[{"mail":"`+testPassword+`"}]`), LegacyVaultKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	memoryKey := &VaultKey{Key: testKeyA}
+	if memoryKey.Header, err = NewVaultHeader(testKDF); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"headerless", legacy},
+		{"v1", sealVersion(t, vaultVersion1, memoryKey, plaintext)},
+		{"v2", sealVersion(t, vaultVersion2, memoryKey, plaintext)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "resource.json")
+			if err := os.WriteFile(path, tt.data, 0600); err != nil {
+				t.Fatal(err)
+			}
+			vault, err := UnlockVault(path, NewMemoryKeyProvider(testKeyA))
+			if err != nil {
+				t.Fatal(err)
+			}
+			header, err := ReadVaultHeader(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if header.Version != vaultVersion {
+				t.Errorf("file not upgraded: version %d", header.Version)
+			}
+			again, err := ReadContent(path, NewMemoryKeyProvider(testKeyA))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(again.entriesByID()) != len(vault.entriesByID()) || len(vault.entriesByID()) == 0 {
+				t.Errorf("content changed after upgrade")
+			}
+		})
+	}
+}
+
+func TestPeekContentReadOnly(t *testing.T) {
+	memoryKey := &VaultKey{Key: testKeyA}
+	var err error
+	if memoryKey.Header, err = NewVaultHeader(testKDF); err != nil {
+		t.Fatal(err)
+	}
+	data := sealVersion(t, vaultVersion1, memoryKey, testVaultJSON(t))
+	path := filepath.Join(t.TempDir(), "resource.json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := PeekContent(path, NewMemoryKeyProvider(testKeyA)); err != nil {
+		t.Fatal(err)
+	}
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(after, data) {
+		t.Error("PeekContent should not upgrade the file")
+	}
+}
+
+func TestUnlockVaultV1Garbage(t *testing.T) {
+	// 模拟错误的密钥恰好通过 CBC 填充检查：解密成功但内容不是 JSON
+	memoryKey := &VaultKey{Key: testKeyA}
+	var err error
+	if memoryKey.Header, err = NewVaultHeader(testKDF); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "resource.json")
+	if err := os.WriteFile(path, sealVersion(t, vaultVersion1, memoryKey, []byte{0x9c, 0x01, 0x7f}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_, err = UnlockVault(path, NewMemoryKeyProvider(testKeyA))
+	var readErr *VaultReadError
+	if !errors.As(err, &readErr) || readErr.Kind != ReadErrorDecrypt || !errors.Is(err, ErrWrongPassword) {
+		t.Errorf("got %v, want a wrong password error", err)
+	}
+	if IsVaultCorrupt(err) {
+		t.Error("a v1 file that fails to parse must not be quarantined")
+	}
+}
+
+func TestUnlockVaultShortLegacyFile(t *testing.T) {
+	for _, size := range []int{0, 5, 16, 17} {
+		path := filepath.Join(t.TempDir(), "resource.json")
+		if err := os.WriteFile(path, bytes.Repeat([]byte{1}, size), 0600); err != nil {
+			t.Fatal(err)
+		}
+		// 16 字节的文件只有 IV，没有密文
+		_, err := UnlockVault(path, NewMemoryKeyProvider(testKeyA))
+		if !IsVaultCorrupt(err) {
+			t.Errorf("size %d: got %v, want a corrupt error", size, err)
+		}
+	}
+}
+
+func TestRekeyFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "resource.json")
+	vault, _ := testVault()
+	if err := SaveContent(path, NewMemoryKeyProvider(testKeyA), vault); err != nil {
+		t.Fatal(err)
+	}
+	before, err := ReadVaultHeader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RekeyFile(path, NewMemoryKeyProvider(testKeyB), NewMemoryKeyProvider(testKeyA)); err == nil {
+		t.Fatal("rekey with the wrong old key should fail")
+	}
+	if err := RekeyFile(path, NewMemoryKeyProvider(testKeyA), NewMemoryKeyProvider(testKeyB)); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := ReadVaultHeader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(before.Salt, after.Salt) {
+		t.Error("rekey should use a new salt")
+	}
+	if _, err := ReadContent(path, NewMemoryKeyProvider(testKeyB)); err != nil {
+		t.Errorf("new key: %v", err)
+	}
+	var readErr *VaultReadError
+	if _, err := ReadContent(path, NewMemoryKeyProvider(testKeyA)); !errors.As(err, &readErr) || readErr.Kind != ReadErrorDecrypt {
+		t.Errorf("old key: got %v, want a decrypt error", err)
+	}
+	assertOnlyFiles(t, dir, "resource.json")
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "resource.json")
+	for _, content := range []string{"first", "second"} {
+		if err := WriteFileAtomic(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Errorf("got %q, want %q", data, content)
+		}
+	}
+	assertOnlyFiles(t, dir, "resource.json")
+}
+
+// assertOnlyFiles 确认 dir 中没有留下临时文件
+func assertOnlyFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Name())
+	}
+	if strings.Join(got, ",") != strings.Join(names, ",") {
+		t.Errorf("files in %s: %v, want %v", dir, got, names)
+	}
+}
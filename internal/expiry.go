@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+var ErrInvalidRotation = errors.New("rotation interval must not be negative")
+
+// ExpiryItem 过期报告中的一个条目
+type ExpiryItem struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Path      []string  `json:"path"` // 所在文件夹从根开始的名字
+	ExpiresAt time.Time `json:"expiresAt"`
+	Expired   bool      `json:"expired"`
+}
+
+// SetExpiry 设置条目的过期时间和轮换周期（天）。expiresAt 为 nil 且设置了轮换周期时，从现在起算
+func (v *Vault) SetExpiry(id string, expiresAt *time.Time, rotationDays int) error {
+	entry, err := v.entry(id)
+	if err != nil {
+		return err
+	}
+	if rotationDays < 0 {
+		return ErrInvalidRotation
+	}
+	now := time.Now()
+	if expiresAt == nil && rotationDays > 0 {
+		next := now.AddDate(0, 0, rotationDays)
+		expiresAt = &next
+	}
+	entry.ExpiresAt = expiresAt
+	entry.RotationDays = rotationDays
+	entry.Modified = now
+	return nil
+}
+
+// rotated 新字段中是否有机密字段（见 isSecret）的值与原来不同，即密码已经轮换
+func rotated(old []Field, fields []Field) bool {
+	for _, f := range fields {
+		if !f.isSecret() {
+			continue
+		}
+		prev, found := Field{}, false
+		for _, o := range old {
+			if o.Name == f.Name {
+				prev, found = o, true
+				break
+			}
+		}
+		if !found || prev.Value != f.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// ExpiryReport 列出目录树中已过期和 within 时间内将要过期的条目（不含回收站），按过期时间排序
+func (v *Vault) ExpiryReport(now time.Time, within time.Duration) []ExpiryItem {
+	report := []ExpiryItem{}
+	var walk func(folder *Folder)
+	walk = func(folder *Folder) {
+		for _, child := range folder.Children {
+			switch n := child.(type) {
+			case *Entry:
+				if n.ExpiresAt != nil && n.ExpiresAt.Sub(now) <= within {
+					report = append(report, ExpiryItem{
+						ID:        n.ID,
+						Name:      n.Name,
+						Path:      v.pathTo(folder),
+						ExpiresAt: *n.ExpiresAt,
+						Expired:   !n.ExpiresAt.After(now),
+					})
+				}
+			case *Folder:
+				walk(n)
+			}
+		}
+	}
+	walk(v.Root)
+	sort.SliceStable(report, func(i, j int) bool {
+		return report[i].ExpiresAt.Before(report[j].ExpiresAt)
+	})
+	return report
+}
@@ -0,0 +1,38 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUpdateEntryRenewsExpiry(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  []Field
+		renewed bool
+	}{
+		{"plain value", []Field{{Name: FieldValue, Value: "new"}}, true},
+		{"password", []Field{{Name: FieldPassword, Value: "new"}}, true},
+		{"sensitive custom", []Field{{Name: FieldValue, Value: "old"}, {Name: "pin", Value: "1234", Sensitive: true}}, true},
+		{"username only", []Field{{Name: FieldValue, Value: "old"}, {Name: FieldUsername, Value: "octocat"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewVault()
+			entry, err := v.CreateEntry("", "router", []Field{{Name: FieldValue, Value: "old"}}, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expired := time.Now().Add(-time.Hour)
+			if err := v.SetExpiry(entry.ID, &expired, 30); err != nil {
+				t.Fatal(err)
+			}
+			if err := v.UpdateEntry(entry.ID, tt.fields, "", 5); err != nil {
+				t.Fatal(err)
+			}
+			if renewed := entry.ExpiresAt.After(time.Now()); renewed != tt.renewed {
+				t.Errorf("renewed = %v, want %v", renewed, tt.renewed)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrHistoryNotFound = errors.New("history item not found")
+
+// FieldVersion 字段被修改或删除前的值，保存在条目中，和密码库一起加密
+type FieldVersion struct {
+	Field
+	Changed time.Time `json:"changed"`
+	Reason  string    `json:"reason,omitempty"` // 修改原因，可为空
+}
+
+// recordHistory 把 fields 中将被修改或删除的字段旧值追加到历史末尾，每个条目最多保留 keep 个旧值
+func (e *Entry) recordHistory(fields []Field, reason string, keep int, now time.Time) {
+	for _, old := range e.Fields {
+		changed := true
+		for _, f := range fields {
+			if f.Name == old.Name {
+				changed = f.Value != old.Value
+				break
+			}
+		}
+		if changed && old.Value != "" {
+			e.History = append(e.History, FieldVersion{Field: old, Changed: now, Reason: reason})
+		}
+	}
+	if keep < 0 {
+		keep = 0
+	}
+	if len(e.History) > keep {
+		e.History = append([]FieldVersion{}, e.History[len(e.History)-keep:]...)
+	}
+}
+
+// HistoryItem 返回条目历史中下标为 index 的旧值，下标按 Entry.History 的顺序（从旧到新）
+func (v *Vault) HistoryItem(id string, index int) (FieldVersion, error) {
+	entry, err := v.entry(id)
+	if err != nil {
+		return FieldVersion{}, err
+	}
+	if index < 0 || index >= len(entry.History) {
+		return FieldVersion{}, ErrHistoryNotFound
+	}
+	return entry.History[index], nil
+}
+
+// RestoreHistory 把旧值写回同名字段（字段已删除时追加到末尾），当前值同样记入历史，恢复本身也可以撤回
+func (v *Vault) RestoreHistory(id string, index int, keep int) error {
+	entry, err := v.entry(id)
+	if err != nil {
+		return err
+	}
+	if index < 0 || index >= len(entry.History) {
+		return ErrHistoryNotFound
+	}
+	old := entry.History[index]
+
+	fields := append([]Field{}, entry.Fields...)
+	found := false
+	for i, f := range fields {
+		if f.Name == old.Name {
+			fields[i].Value = old.Value
+			found = true
+			break
+		}
+	}
+	if !found {
+		fields = append(fields, old.Field)
+	}
+	now := time.Now()
+	entry.recordHistory(fields, "restore "+old.Changed.Format("2006-01-02 15:04"), keep, now)
+	entry.Fields = fields
+	entry.Modified = now
+	return nil
+}
@@ -0,0 +1,284 @@
+package internal
+
+import (
+	"crypto/rand"
+	_ "embed"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// 生成模式
+const (
+	GeneratorChars         = "chars"         // 随机字符
+	GeneratorPronounceable = "pronounceable" // 辅音元音交替，便于读出和手动输入
+	GeneratorPassphrase    = "passphrase"    // diceware 单词短语
+)
+
+const (
+	lowerChars     = "abcdefghijklmnopqrstuvwxyz"
+	upperChars     = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digitChars     = "0123456789"
+	symbolChars    = "!@#$%^&*()-_=+[]{};:,.<>/?~"
+	ambiguousChars = "0Oo1lI|5S2Z8B`'\""
+	consonants     = "bcdfghjkmnprstvwxz"
+	vowels         = "aeiu"
+)
+
+var ErrGeneratorPolicy = errors.New("invalid generator policy")
+
+// eff_large_wordlist.txt 来自 EFF 的 diceware 长词表（7776 个单词，CC BY 3.0）：
+// https://www.eff.org/deeplinks/2016/07/new-wordlists-random-passphrases
+//
+//go:embed asset/eff_large_wordlist.txt
+var wordlistData string
+
+var wordlist = sync.OnceValue(func() []string {
+	lines := strings.Split(strings.TrimSpace(wordlistData), "\n")
+	words := make([]string, 0, len(lines))
+	for _, line := range lines {
+		// 每行是 "骰子点数<TAB>单词"
+		if _, word, ok := strings.Cut(strings.TrimSpace(line), "\t"); ok {
+			words = append(words, word)
+		}
+	}
+	return words
+})
+
+// GeneratorPolicy 密码生成规则，可以作为全局默认值或某个文件夹的默认值
+type GeneratorPolicy struct {
+	Mode             string `json:"mode"`
+	Length           int    `json:"length"` // chars 和 pronounceable 模式的长度
+	Lower            bool   `json:"lower"`
+	Upper            bool   `json:"upper"`
+	Digits           bool   `json:"digits"`
+	Symbols          bool   `json:"symbols"`
+	ExcludeAmbiguous bool   `json:"excludeAmbiguous"` // 排除 0/O、1/l/I 等容易看错的字符
+	RequireEach      bool   `json:"requireEach"`      // 每种选中的字符至少出现一次
+	Words            int    `json:"words"`            // passphrase 模式的单词数
+	Separator        string `json:"separator"`
+	Capitalize       bool   `json:"capitalize"` // passphrase 模式单词首字母大写
+}
+
+func DefaultGeneratorPolicy() GeneratorPolicy {
+	return GeneratorPolicy{
+		Mode:             GeneratorChars,
+		Length:           20,
+		Lower:            true,
+		Upper:            true,
+		Digits:           true,
+		Symbols:          true,
+		ExcludeAmbiguous: true,
+		RequireEach:      true,
+		Words:            6,
+		Separator:        "-",
+		Capitalize:       false,
+	}
+}
+
+// randInt 用 crypto/rand 返回 [0, n) 中均匀分布的整数
+func randInt(n int) int {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		panic(err)
+	}
+	return int(i.Int64())
+}
+
+func randChar(set string) byte {
+	return set[randInt(len(set))]
+}
+
+func shuffle[T any](s []T) {
+	for i := len(s) - 1; i > 0; i-- {
+		j := randInt(i + 1)
+		s[i], s[j] = s[j], s[i]
+	}
+}
+
+func withoutAmbiguous(set string, exclude bool) string {
+	if !exclude {
+		return set
+	}
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(ambiguousChars, r) {
+			return -1
+		}
+		return r
+	}, set)
+}
+
+// charSets 按规则选中的字符集，顺序为小写、大写、数字、符号
+func (p GeneratorPolicy) charSets() []string {
+	var sets []string
+	for _, c := range []struct {
+		on  bool
+		set string
+	}{{p.Lower, lowerChars}, {p.Upper, upperChars}, {p.Digits, digitChars}, {p.Symbols, symbolChars}} {
+		if c.on {
+			sets = append(sets, withoutAmbiguous(c.set, p.ExcludeAmbiguous))
+		}
+	}
+	return sets
+}
+
+// Validate 检查规则是否能生成结果
+func (p GeneratorPolicy) Validate() error {
+	switch p.Mode {
+	case GeneratorChars, GeneratorPronounceable:
+		if p.Length < 4 || p.Length > 256 {
+			return fmt.Errorf("%w: length must be between 4 and 256", ErrGeneratorPolicy)
+		}
+		if p.Mode == GeneratorChars && len(p.charSets()) == 0 {
+			return fmt.Errorf("%w: no character class selected", ErrGeneratorPolicy)
+		}
+		if p.RequireEach && len(p.charSets()) > p.Length {
+			return fmt.Errorf("%w: length is shorter than the number of required classes", ErrGeneratorPolicy)
+		}
+	case GeneratorPassphrase:
+		if p.Words < 3 || p.Words > 32 {
+			return fmt.Errorf("%w: words must be between 3 and 32", ErrGeneratorPolicy)
+		}
+	default:
+		return fmt.Errorf("%w: unknown mode %q", ErrGeneratorPolicy, p.Mode)
+	}
+	return nil
+}
+
+// Generate 按规则生成密码或短语
+func Generate(p GeneratorPolicy) (string, error) {
+	if err := p.Validate(); err != nil {
+		return "", err
+	}
+	switch p.Mode {
+	case GeneratorPronounceable:
+		return generatePronounceable(p), nil
+	case GeneratorPassphrase:
+		return generatePassphrase(p), nil
+	default:
+		return generateChars(p), nil
+	}
+}
+
+func generateChars(p GeneratorPolicy) string {
+	sets := p.charSets()
+	all := strings.Join(sets, "")
+	b := make([]byte, 0, p.Length)
+	if p.RequireEach {
+		for _, set := range sets {
+			b = append(b, randChar(set))
+		}
+	}
+	for len(b) < p.Length {
+		b = append(b, randChar(all))
+	}
+	shuffle(b)
+	return string(b)
+}
+
+// generatePronounceable 生成辅音元音交替的小写字母串，再把不同的随机位置各替换为一个选中的大写字母、数字和符号
+func generatePronounceable(p GeneratorPolicy) string {
+	cons := withoutAmbiguous(consonants, p.ExcludeAmbiguous)
+	b := make([]byte, p.Length)
+	for i := range b {
+		if i%2 == 0 {
+			b[i] = randChar(cons)
+		} else {
+			b[i] = randChar(vowels)
+		}
+	}
+
+	var extras []string
+	if p.Digits {
+		extras = append(extras, withoutAmbiguous(digitChars, p.ExcludeAmbiguous))
+	}
+	if p.Symbols {
+		extras = append(extras, withoutAmbiguous(symbolChars, p.ExcludeAmbiguous))
+	}
+	positions := make([]int, p.Length)
+	for i := range positions {
+		positions[i] = i
+	}
+	shuffle(positions)
+	next := 0
+	if p.Upper {
+		for k, i := range positions {
+			upper := byte(unicode.ToUpper(rune(b[i])))
+			if !p.ExcludeAmbiguous || !strings.ContainsRune(ambiguousChars, rune(upper)) {
+				b[i] = upper
+				positions[0], positions[k] = positions[k], positions[0]
+				next++
+				break
+			}
+		}
+		// 每个字母的大写都容易看错时，直接换成一个不易看错的大写字母
+		if next == 0 {
+			b[positions[0]] = randChar(withoutAmbiguous(upperChars, p.ExcludeAmbiguous))
+			next++
+		}
+	}
+	for _, set := range extras {
+		b[positions[next]] = randChar(set)
+		next++
+	}
+	return string(b)
+}
+
+func generatePassphrase(p GeneratorPolicy) string {
+	words := wordlist()
+	parts := make([]string, p.Words)
+	for i := range parts {
+		word := words[randInt(len(words))]
+		if p.Capitalize {
+			word = strings.ToUpper(word[:1]) + word[1:]
+		}
+		parts[i] = word
+	}
+	// 要求数字或符号时追加到随机一个单词后面
+	if p.Digits {
+		i := randInt(len(parts))
+		parts[i] += string(randChar(digitChars))
+	}
+	if p.Symbols {
+		i := randInt(len(parts))
+		parts[i] += string(randChar(symbolChars))
+	}
+	return strings.Join(parts, p.Separator)
+}
+
+// GeneratorPolicyFor 返回在 folderID 下新建条目时使用的规则：从该文件夹向上找到的第一个文件夹规则，
+// 都没有时使用 fallback
+func (v *Vault) GeneratorPolicyFor(folderID string, fallback GeneratorPolicy) (GeneratorPolicy, error) {
+	folder, err := v.folder(folderID)
+	if err != nil {
+		return GeneratorPolicy{}, err
+	}
+	for folder != nil {
+		if folder.Generator != nil {
+			return *folder.Generator, nil
+		}
+		_, parent, _ := v.Find(folder.ID)
+		folder = parent
+	}
+	return fallback, nil
+}
+
+// SetGeneratorPolicy 设置文件夹的默认生成规则，policy 为 nil 表示继承上级
+func (v *Vault) SetGeneratorPolicy(folderID string, policy *GeneratorPolicy) error {
+	folder, err := v.folder(folderID)
+	if err != nil {
+		return err
+	}
+	if policy != nil {
+		if err := policy.Validate(); err != nil {
+			return err
+		}
+	}
+	folder.Generator = policy
+	folder.Modified = time.Now()
+	return nil
+}
@@ -0,0 +1,23 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPronounceableUpperWithoutAmbiguous(t *testing.T) {
+	p := GeneratorPolicy{Mode: GeneratorPronounceable, Length: 4, Upper: true, ExcludeAmbiguous: true}
+	// 长度为 4 时所有字母的大写都容易看错（如 "bisi"）的情况足够常见，多生成几次基本都能覆盖到
+	for range 2000 {
+		got, err := Generate(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.ContainsAny(got, upperChars) {
+			t.Fatalf("%q has no capital letter", got)
+		}
+		if strings.ContainsAny(got, ambiguousChars) {
+			t.Fatalf("%q contains an ambiguous character", got)
+		}
+	}
+}
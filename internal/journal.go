@@ -0,0 +1,501 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+)
+
+var (
+	ErrNothingToUndo   = errors.New("nothing to undo")
+	ErrNothingToRedo   = errors.New("nothing to redo")
+	ErrJournalMismatch = errors.New("undo history does not match the vault")
+)
+
+// journalVersion 持久化格式的版本。版本 1 保存完整快照，读取时直接丢弃
+const journalVersion = 2
+
+// journalVaultKey 节点状态表中密码库本身（根文件夹 ID 和回收站列表）使用的键，其它键都是节点 ID
+const journalVaultKey = ""
+
+// journalNode 撤销历史中一个节点的状态：条目的完整内容，或文件夹去掉子节点后的内容加上子节点的 ID。
+// 键为 journalVaultKey 时只有 Vault
+type journalNode struct {
+	Node     json.RawMessage `json:"node,omitempty"`
+	Children []string        `json:"children,omitempty"`
+	Vault    *journalVault   `json:"vault,omitempty"`
+}
+
+// journalVault 密码库本身的结构，节点按 ID 单独保存
+type journalVault struct {
+	Version int            `json:"version"`
+	Root    string         `json:"root"`
+	Trash   []journalTrash `json:"trash"`
+}
+
+// journalTrash 回收站中的一项，字段与 TrashItem 相同，节点本身按 ID 单独保存
+type journalTrash struct {
+	ID       string    `json:"id"`
+	ParentID string    `json:"parentId"`
+	Path     []string  `json:"path"`
+	Index    int       `json:"index"`
+	Deleted  time.Time `json:"deleted"`
+}
+
+// JournalEntry 一次修改操作，只保存被修改的节点在修改前后的状态，nil 表示该节点不存在
+type JournalEntry struct {
+	Op     string                  `json:"op"`
+	Time   time.Time               `json:"time"`
+	Before map[string]*journalNode `json:"before"`
+	After  map[string]*journalNode `json:"after"`
+	Blobs  []string                `json:"blobs,omitempty"` // 这些节点引用的附件 ID
+}
+
+// Journal 撤销/重做日志。节点状态包含明文内容，持久化时用密码库的密钥加密。
+// 回收站中被永久删除的节点和条目历史中被裁掉的旧值会从整个日志中删除，不能再通过撤销找回。
+// 节点状态中不含附件内容，附件按 ID 在 Blobs 中只保存一份
+type Journal struct {
+	Version int               `json:"version"`
+	Undo    []JournalEntry    `json:"undo"`
+	Redo    []JournalEntry    `json:"redo"`
+	Blobs   map[string][]byte `json:"blobs"`
+	limit   int
+}
+
+// NewJournal limit 为最多保留的撤销步数，<= 0 表示不限制
+func NewJournal(limit int) *Journal {
+	return &Journal{Version: journalVersion, Undo: []JournalEntry{}, Redo: []JournalEntry{}, Blobs: map[string][]byte{}, limit: limit}
+}
+
+// flattenVault 把密码库拆成按 ID 索引的节点状态，trashed 为回收站中（包括回收站里文件夹下）的节点 ID
+func flattenVault(v *Vault) (nodes map[string]*journalNode, trashed map[string]bool, err error) {
+	nodes, trashed = make(map[string]*journalNode), make(map[string]bool)
+	var add func(node Node, inTrash bool) error
+	add = func(node Node, inTrash bool) error {
+		state := &journalNode{}
+		switch n := node.(type) {
+		case *Entry:
+			if state.Node, err = json.Marshal(n); err != nil {
+				return err
+			}
+		case *Folder:
+			shallow := *n
+			shallow.Children = nil
+			if state.Node, err = json.Marshal(&shallow); err != nil {
+				return err
+			}
+			for _, child := range n.Children {
+				state.Children = append(state.Children, child.NodeID())
+				if err := add(child, inTrash); err != nil {
+					return err
+				}
+			}
+		}
+		nodes[node.NodeID()] = state
+		if inTrash {
+			trashed[node.NodeID()] = true
+		}
+		return nil
+	}
+
+	shell := &journalVault{Version: v.Version, Root: v.Root.ID, Trash: []journalTrash{}}
+	if err := add(v.Root, false); err != nil {
+		return nil, nil, err
+	}
+	for _, item := range v.Trash {
+		shell.Trash = append(shell.Trash, journalTrash{
+			ID:       item.Node.NodeID(),
+			ParentID: item.ParentID,
+			Path:     item.Path,
+			Index:    item.Index,
+			Deleted:  item.Deleted,
+		})
+		if err := add(item.Node, true); err != nil {
+			return nil, nil, err
+		}
+	}
+	nodes[journalVaultKey] = &journalNode{Vault: shell}
+	return nodes, trashed, nil
+}
+
+func sameState(a *journalNode, b *journalNode) (bool, error) {
+	x, err := json.Marshal(a)
+	if err != nil {
+		return false, err
+	}
+	y, err := json.Marshal(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(x, y), nil
+}
+
+// Record 记录一次修改，新的修改会清空重做栈
+func (j *Journal) Record(op string, before *Vault, after *Vault) error {
+	old, trashed, err := flattenVault(before)
+	if err != nil {
+		return err
+	}
+	current, _, err := flattenVault(after)
+	if err != nil {
+		return err
+	}
+
+	entry := JournalEntry{Op: op, Time: time.Now(), Before: map[string]*journalNode{}, After: map[string]*journalNode{}}
+	var purged []string
+	for id, state := range old {
+		next, ok := current[id]
+		if !ok && trashed[id] {
+			purged = append(purged, id)
+			continue
+		}
+		if ok {
+			if same, err := sameState(state, next); err != nil {
+				return err
+			} else if same {
+				continue
+			}
+		}
+		entry.Before[id], entry.After[id] = state, next
+	}
+	for id, next := range current {
+		if _, ok := old[id]; !ok {
+			entry.Before[id], entry.After[id] = nil, next
+		}
+	}
+
+	// 回收站中被永久删除的节点不保存，之前的记录中也一并删除
+	if len(purged) > 0 {
+		if err := j.forgetNodes(purged); err != nil {
+			return err
+		}
+	}
+	// 条目历史中被裁掉的旧值同样从所有记录中删除
+	oldEntries, newEntries := before.entriesByID(), after.entriesByID()
+	for id := range entry.Before {
+		prev, next := oldEntries[id], newEntries[id]
+		if prev == nil || next == nil {
+			continue
+		}
+		var dropped []FieldVersion
+		for _, h := range prev.History {
+			if !slices.ContainsFunc(next.History, h.same) {
+				dropped = append(dropped, h)
+			}
+		}
+		if len(dropped) == 0 {
+			continue
+		}
+		if err := j.forgetHistory(id, dropped); err != nil {
+			return err
+		}
+		if entry.Before[id], err = entry.Before[id].withoutHistory(dropped); err != nil {
+			return err
+		}
+	}
+
+	for id := range entry.Before {
+		for _, v := range []*Vault{before, after} {
+			if e := v.entriesByID()[id]; e != nil {
+				for _, a := range e.Attachments {
+					if data, ok := v.Blobs[a.ID]; ok {
+						j.Blobs[a.ID] = data
+						entry.Blobs = append(entry.Blobs, a.ID)
+					}
+				}
+			}
+		}
+	}
+
+	j.Undo = append(j.Undo, entry)
+	if j.limit > 0 && len(j.Undo) > j.limit {
+		j.Undo = j.Undo[len(j.Undo)-j.limit:]
+	}
+	j.Redo = []JournalEntry{}
+	j.pruneBlobs()
+	return nil
+}
+
+// same 两个旧值是否为同一条历史
+func (h FieldVersion) same(other FieldVersion) bool {
+	return h.Field == other.Field && h.Changed.Equal(other.Changed) && h.Reason == other.Reason
+}
+
+// withoutHistory 去掉条目状态中 dropped 里的历史旧值，返回新的状态；不是条目时原样返回
+func (s *journalNode) withoutHistory(dropped []FieldVersion) (*journalNode, error) {
+	if s == nil || s.Node == nil {
+		return s, nil
+	}
+	node, err := decodeNode(s.Node)
+	if err != nil {
+		return nil, err
+	}
+	entry, ok := node.(*Entry)
+	if !ok {
+		return s, nil
+	}
+	entry.History = slices.DeleteFunc(entry.History, func(h FieldVersion) bool {
+		return slices.ContainsFunc(dropped, h.same)
+	})
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	return &journalNode{Node: data}, nil
+}
+
+// entries 撤销栈和重做栈中所有记录的指针
+func (j *Journal) entries() []*JournalEntry {
+	var all []*JournalEntry
+	for i := range j.Undo {
+		all = append(all, &j.Undo[i])
+	}
+	for i := range j.Redo {
+		all = append(all, &j.Redo[i])
+	}
+	return all
+}
+
+// forgetNodes 从所有记录中删除节点 ids 的状态，并重新统计记录引用的附件
+func (j *Journal) forgetNodes(ids []string) error {
+	for _, entry := range j.entries() {
+		changed := false
+		for _, id := range ids {
+			if _, ok := entry.Before[id]; ok {
+				delete(entry.Before, id)
+				delete(entry.After, id)
+				changed = true
+			}
+		}
+		if changed {
+			if err := entry.refreshBlobs(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// forgetHistory 从所有记录中条目 id 的状态里删除 dropped 中的历史旧值
+func (j *Journal) forgetHistory(id string, dropped []FieldVersion) error {
+	for _, entry := range j.entries() {
+		for _, states := range []map[string]*journalNode{entry.Before, entry.After} {
+			if state, ok := states[id]; ok {
+				var err error
+				if states[id], err = state.withoutHistory(dropped); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// refreshBlobs 按记录中剩下的条目状态重新统计引用的附件
+func (e *JournalEntry) refreshBlobs() error {
+	var blobs []string
+	for _, states := range []map[string]*journalNode{e.Before, e.After} {
+		for _, state := range states {
+			if state == nil || state.Node == nil {
+				continue
+			}
+			node, err := decodeNode(state.Node)
+			if err != nil {
+				return err
+			}
+			if entry, ok := node.(*Entry); ok {
+				for _, a := range entry.Attachments {
+					blobs = append(blobs, a.ID)
+				}
+			}
+		}
+	}
+	e.Blobs = blobs
+	return nil
+}
+
+// pruneBlobs 删除已不被任何日志条目引用的附件内容
+func (j *Journal) pruneBlobs() {
+	ids := make(map[string]bool)
+	for _, entries := range [][]JournalEntry{j.Undo, j.Redo} {
+		for _, entry := range entries {
+			for _, id := range entry.Blobs {
+				ids[id] = true
+			}
+		}
+	}
+	for id := range j.Blobs {
+		if !ids[id] {
+			delete(j.Blobs, id)
+		}
+	}
+}
+
+// apply 把 states 中的节点状态套用到 current 上，得到撤销或重做之后的密码库，current 本身不变。
+// 已经永久删除、没有状态的节点被跳过
+func (j *Journal) apply(current *Vault, states map[string]*journalNode) (*Vault, error) {
+	nodes, _, err := flattenVault(current)
+	if err != nil {
+		return nil, err
+	}
+	for id, state := range states {
+		if state == nil {
+			delete(nodes, id)
+		} else {
+			nodes[id] = state
+		}
+	}
+	shell := nodes[journalVaultKey].Vault
+	if shell == nil {
+		return nil, ErrJournalMismatch
+	}
+
+	used := make(map[string]bool)
+	var build func(id string) (Node, error)
+	build = func(id string) (Node, error) {
+		state := nodes[id]
+		if used[id] || state.Vault != nil {
+			return nil, fmt.Errorf("%w: node %s", ErrJournalMismatch, id)
+		}
+		used[id] = true
+		node, err := decodeNode(state.Node)
+		if err != nil {
+			return nil, err
+		}
+		if folder, ok := node.(*Folder); ok {
+			for _, childID := range state.Children {
+				if nodes[childID] == nil {
+					continue
+				}
+				child, err := build(childID)
+				if err != nil {
+					return nil, err
+				}
+				folder.Children = append(folder.Children, child)
+			}
+		}
+		return node, nil
+	}
+
+	if nodes[shell.Root] == nil {
+		return nil, fmt.Errorf("%w: root folder", ErrJournalMismatch)
+	}
+	root, err := build(shell.Root)
+	if err != nil {
+		return nil, err
+	}
+	rootFolder, ok := root.(*Folder)
+	if !ok {
+		return nil, fmt.Errorf("%w: root is not a folder", ErrJournalMismatch)
+	}
+	vault := &Vault{Version: shell.Version, Root: rootFolder, Trash: []*TrashItem{}, AuditSeq: current.AuditSeq}
+	for _, t := range shell.Trash {
+		if nodes[t.ID] == nil {
+			continue
+		}
+		node, err := build(t.ID)
+		if err != nil {
+			return nil, err
+		}
+		vault.Trash = append(vault.Trash, &TrashItem{Node: node, ParentID: t.ParentID, Path: t.Path, Index: t.Index, Deleted: t.Deleted})
+	}
+	// 不在目录树和回收站中的节点说明密码库在日志之外被修改过，不能安全地撤销
+	if len(used) != len(nodes)-1 {
+		return nil, fmt.Errorf("%w: %d nodes are no longer reachable", ErrJournalMismatch, len(nodes)-1-len(used))
+	}
+
+	for id := range vault.referencedBlobs() {
+		data, ok := current.Blobs[id]
+		if !ok {
+			data, ok = j.Blobs[id]
+		}
+		if ok {
+			if vault.Blobs == nil {
+				vault.Blobs = make(map[string][]byte)
+			}
+			vault.Blobs[id] = data
+		}
+	}
+	return vault, nil
+}
+
+// PeekUndo 返回在 current 上撤销最近一次修改后应有的内容和对应的操作名，不修改日志。
+// 调用方保存成功后再调用 CommitUndo，保存失败时日志保持原样
+func (j *Journal) PeekUndo(current *Vault) (*Vault, string, error) {
+	if len(j.Undo) == 0 {
+		return nil, "", ErrNothingToUndo
+	}
+	entry := j.Undo[len(j.Undo)-1]
+	vault, err := j.apply(current, entry.Before)
+	return vault, entry.Op, err
+}
+
+func (j *Journal) CommitUndo() {
+	entry := j.Undo[len(j.Undo)-1]
+	j.Undo = j.Undo[:len(j.Undo)-1]
+	j.Redo = append(j.Redo, entry)
+}
+
+// PeekRedo 与 PeekUndo 相同，方向相反
+func (j *Journal) PeekRedo(current *Vault) (*Vault, string, error) {
+	if len(j.Redo) == 0 {
+		return nil, "", ErrNothingToRedo
+	}
+	entry := j.Redo[len(j.Redo)-1]
+	vault, err := j.apply(current, entry.After)
+	return vault, entry.Op, err
+}
+
+func (j *Journal) CommitRedo() {
+	entry := j.Redo[len(j.Redo)-1]
+	j.Redo = j.Redo[:len(j.Redo)-1]
+	j.Undo = append(j.Undo, entry)
+}
+
+// Save 用密码库当前的密钥 key 加密日志并原子写入 path
+func (j *Journal) Save(path string, key *VaultKey) error {
+	data, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+	sealed, err := SealVault(key, data)
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(path, sealed, 0600)
+}
+
+// LoadJournal 读取并解密 path 处的日志，文件不存在或是旧版本的格式时返回空日志
+func LoadJournal(path string, keys KeyProvider, limit int) (*Journal, error) {
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return NewJournal(limit), nil
+	} else if err != nil {
+		return nil, err
+	}
+	_, _, plaintext, err := OpenVault(raw, keys)
+	if err != nil {
+		return nil, err
+	}
+	var probe struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(plaintext, &probe); err != nil {
+		return nil, err
+	}
+	if probe.Version != journalVersion {
+		return NewJournal(limit), nil
+	}
+	j := NewJournal(limit)
+	if err := json.Unmarshal(plaintext, j); err != nil {
+		return nil, err
+	}
+	if j.Blobs == nil {
+		j.Blobs = map[string][]byte{}
+	}
+	return j, nil
+}
@@ -0,0 +1,179 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// recordStep 在 v 的副本上执行 fn 并记入 j，返回修改后的密码库
+func recordStep(t *testing.T, j *Journal, v *Vault, op string, fn func(v *Vault) error) *Vault {
+	t.Helper()
+	next, err := v.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fn(next); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Record(op, v, next); err != nil {
+		t.Fatal(err)
+	}
+	return next
+}
+
+func marshalVault(t *testing.T, v *Vault) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestJournalUndoRedo(t *testing.T) {
+	j := NewJournal(0)
+	v0, entry := testVault()
+	var folderID string
+	v1 := recordStep(t, j, v0, "create-folder", func(v *Vault) error {
+		folder, err := v.CreateFolder("", "work", -1)
+		folderID = folder.ID
+		return err
+	})
+	v2 := recordStep(t, j, v1, "move", func(v *Vault) error { return v.MoveNode(entry.ID, folderID, 0) })
+	v3 := recordStep(t, j, v2, "edit", func(v *Vault) error {
+		return v.UpdateEntry(entry.ID, []Field{{Name: FieldPassword, Value: "new-password"}}, "", 5)
+	})
+	v4 := recordStep(t, j, v3, "delete", func(v *Vault) error { return v.DeleteNode(folderID) })
+
+	states := []*Vault{v0, v1, v2, v3, v4}
+	current := v4
+	for i := len(states) - 2; i >= 0; i-- {
+		vault, _, err := j.PeekUndo(current)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := marshalVault(t, vault), marshalVault(t, states[i]); got != want {
+			t.Fatalf("undo to state %d:\n got %s\nwant %s", i, got, want)
+		}
+		j.CommitUndo()
+		current = vault
+	}
+	for i := 1; i < len(states); i++ {
+		vault, _, err := j.PeekRedo(current)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := marshalVault(t, vault), marshalVault(t, states[i]); got != want {
+			t.Fatalf("redo to state %d:\n got %s\nwant %s", i, got, want)
+		}
+		j.CommitRedo()
+		current = vault
+	}
+
+	// 只保存被修改的节点：移动条目不应该记录根目录以外无关的节点
+	if _, ok := j.Undo[1].Before[entry.ID]; ok {
+		t.Error("moving an entry should not store the entry itself")
+	}
+}
+
+func TestJournalForgetsPurgedNodes(t *testing.T) {
+	j := NewJournal(0)
+	v0, entry := testVault()
+	v1 := recordStep(t, j, v0, "delete", func(v *Vault) error { return v.DeleteNode(entry.ID) })
+	v2 := recordStep(t, j, v1, "purge", func(v *Vault) error { return v.PurgeNode(entry.ID) })
+
+	key := testVaultKey(t)
+	path := filepath.Join(t.TempDir(), "resource.json.journal")
+	if err := j.Save(path, key); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadJournal(path, NewMemoryKeyProvider(key.Key), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertNoSecrets(t, string(data), testPassword, testOldSecret, testToken, testOTPSecret)
+
+	// 撤销清空回收站和删除都不能找回被永久删除的条目
+	current := v2
+	for range 2 {
+		vault, _, err := loaded.PeekUndo(current)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(vault.entriesByID()) != 0 {
+			t.Errorf("purged entry came back after undo")
+		}
+		loaded.CommitUndo()
+		current = vault
+	}
+}
+
+func TestJournalForgetsTrimmedHistory(t *testing.T) {
+	j := NewJournal(0)
+	v0, entry := testVault()
+	v1 := recordStep(t, j, v0, "edit", func(v *Vault) error {
+		return v.UpdateEntry(entry.ID, []Field{{Name: FieldPassword, Value: "second"}}, "", 1)
+	})
+	recordStep(t, j, v1, "edit", func(v *Vault) error {
+		return v.UpdateEntry(entry.ID, []Field{{Name: FieldPassword, Value: "third"}}, "", 1)
+	})
+	data, err := json.Marshal(j)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// testOldSecret 在第一次修改时被裁掉
+	assertNoSecrets(t, string(data), testOldSecret)
+	// 第一次修改后历史中的 testPassword 在第二次修改时被裁掉，撤销第一次修改仍能恢复当时的当前值
+	node, err := decodeNode(j.Undo[0].After[entry.ID].Node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if history := node.(*Entry).History; len(history) != 0 {
+		t.Errorf("trimmed history kept in older states: %+v", history)
+	}
+}
+
+func TestJournalMismatch(t *testing.T) {
+	j := NewJournal(0)
+	v0, entry := testVault()
+	var folderID string
+	v1 := recordStep(t, j, v0, "create-folder", func(v *Vault) error {
+		folder, err := v.CreateFolder("", "work", -1)
+		folderID = folder.ID
+		return err
+	})
+	// 日志之外删除的条目不能被撤销悄悄带回，也不能让撤销失败
+	outside, err := v1.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := outside.PurgeNode(entry.ID); err == nil {
+		t.Fatal("entry is not in the trash")
+	}
+	outside.Root.Children = outside.Root.Children[1:]
+	vault, _, err := j.PeekUndo(outside)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node, _, _ := vault.Find(entry.ID); node != nil {
+		t.Error("entry removed outside the journal came back")
+	}
+
+	// 撤销会删除日志之外新建了子文件夹的文件夹
+	stray, err := v1.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stray.CreateFolder(folderID, "nested", -1); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := j.PeekUndo(stray); !errors.Is(err, ErrJournalMismatch) {
+		t.Errorf("got %v, want ErrJournalMismatch", err)
+	}
+}
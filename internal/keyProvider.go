@@ -0,0 +1,131 @@
+package internal
+
+import (
+	"bytes"
+	"sync"
+)
+
+// KeyProvider 密码库密钥的来源。存储层只通过它取得密钥，
+// 不关心密钥来自主密码、密钥文件还是系统密钥环
+type KeyProvider interface {
+	// ObtainKey 取得与 header 匹配的密钥；header 为 nil 表示新建文件，
+	// 实现可以复用已缓存的密钥，否则生成新的文件头
+	ObtainKey(header *VaultHeader) (*VaultKey, error)
+	// CacheKey 记住已经成功使用过的密钥，之后同一文件头无需重新派生
+	CacheKey(key *VaultKey) error
+	// ForgetKey 清除缓存的密钥和凭据，之后 ObtainKey 返回 ErrVaultLocked
+	ForgetKey() error
+}
+
+// keyMatches 缓存的密钥是否对应 header（salt、KDF 参数和密钥文件要求都相同）
+func keyMatches(key *VaultKey, header *VaultHeader) bool {
+	return key != nil && header != nil &&
+		bytes.Equal(key.Header.Salt, header.Salt) &&
+		key.Header.KDF == header.KDF &&
+		key.Header.KeyFile == header.KeyFile
+}
+
+// CredentialsKeyProvider 用 Argon2id 从主密码（及可选的密钥文件）派生密钥
+type CredentialsKeyProvider struct {
+	mu        sync.Mutex
+	creds     Credentials
+	cached    *VaultKey
+	forgotten bool
+}
+
+// NewPasswordKeyProvider 只用主密码派生密钥
+func NewPasswordKeyProvider(password string) *CredentialsKeyProvider {
+	return &CredentialsKeyProvider{creds: Credentials{Password: password}}
+}
+
+// NewKeyFileKeyProvider 用主密码和密钥文件共同派生密钥
+func NewKeyFileKeyProvider(password string, keyFile string) *CredentialsKeyProvider {
+	return &CredentialsKeyProvider{creds: Credentials{Password: password, KeyFile: keyFile}}
+}
+
+// NewCredentialsKeyProvider 根据 creds 是否带密钥文件选择上面两种之一
+func NewCredentialsKeyProvider(creds Credentials) *CredentialsKeyProvider {
+	if creds.KeyFile != "" {
+		return NewKeyFileKeyProvider(creds.Password, creds.KeyFile)
+	}
+	return NewPasswordKeyProvider(creds.Password)
+}
+
+func (p *CredentialsKeyProvider) ObtainKey(header *VaultHeader) (*VaultKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.forgotten {
+		return nil, ErrVaultLocked
+	}
+	if keyMatches(p.cached, header) || (header == nil && p.cached != nil) {
+		return p.cached, nil
+	}
+	if header == nil {
+		var err error
+		if header, err = newHeaderFor(p.creds); err != nil {
+			return nil, err
+		}
+	}
+	if p.creds.Password == "" {
+		return nil, ErrEmptyPassword
+	}
+	return NewVaultKey(p.creds, header)
+}
+
+func (p *CredentialsKeyProvider) CacheKey(key *VaultKey) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.forgotten {
+		return ErrVaultLocked
+	}
+	p.cached = key
+	return nil
+}
+
+func (p *CredentialsKeyProvider) ForgetKey() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cached = nil
+	p.creds = Credentials{}
+	p.forgotten = true
+	return nil
+}
+
+// MemoryKeyProvider 直接使用内存中的 32 字节密钥，不经过 KDF，用于测试
+type MemoryKeyProvider struct {
+	mu  sync.Mutex
+	key string
+}
+
+func NewMemoryKeyProvider(key string) *MemoryKeyProvider {
+	return &MemoryKeyProvider{key: key}
+}
+
+func (p *MemoryKeyProvider) ObtainKey(header *VaultHeader) (*VaultKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.key == "" {
+		return nil, ErrVaultLocked
+	}
+	if header == nil {
+		var err error
+		if header, err = NewVaultHeader(DefaultKDFParams); err != nil {
+			return nil, err
+		}
+	}
+	return &VaultKey{Header: header, Key: p.key}, nil
+}
+
+func (p *MemoryKeyProvider) CacheKey(key *VaultKey) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.key = key.Key
+	return nil
+}
+
+func (p *MemoryKeyProvider) ForgetKey() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.key = ""
+	return nil
+}
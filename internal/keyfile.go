@@ -0,0 +1,96 @@
+package internal
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+var (
+	ErrKeyFileRequired   = errors.New("vault requires a keyfile")
+	ErrWrongKeyFile      = errors.New("wrong keyfile")
+	ErrKeyFileUnreadable = errors.New("keyfile cannot be read")
+)
+
+// keyFileSize 生成的密钥文件长度
+const keyFileSize = 32
+
+// Credentials 解锁密码库所需的凭据：主密码，以及可选的密钥文件路径
+type Credentials struct {
+	Password string `json:"password"`
+	KeyFile  string `json:"keyFile"`
+}
+
+// GenerateKeyFile 在 path 处生成一个包含 32 字节随机数的密钥文件，不会覆盖已有文件
+func GenerateKeyFile(path string) error {
+	data := make([]byte, keyFileSize)
+	if _, err := io.ReadFull(rand.Reader, data); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// KeyFileHash 任意文件都可以作为密钥文件，参与派生的是其内容的 SHA-256
+func KeyFileHash(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeyFileUnreadable, err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeyFileUnreadable, err)
+	}
+	return h.Sum(nil), nil
+}
+
+// keyFileCheck 写入文件头的密钥文件校验值，用来区分“密钥文件错误”和“密码错误”。
+// 校验值由 salt 加盐，只截取 16 字节
+func keyFileCheck(keyFileHash []byte, salt []byte) []byte {
+	mac := hmac.New(sha256.New, keyFileHash)
+	mac.Write([]byte("quick-clip keyfile"))
+	mac.Write(salt)
+	return mac.Sum(nil)[:16]
+}
+
+// compositeKey 把主密码和密钥文件组合成 KDF 的输入
+func compositeKey(password string, keyFileHash []byte) []byte {
+	if keyFileHash == nil {
+		return []byte(password)
+	}
+	pw := sha256.Sum256([]byte(password))
+	return append(pw[:], keyFileHash...)
+}
+
+// newHeaderFor 为 creds 创建新的文件头，提供了密钥文件时在文件头中记录该要求
+func newHeaderFor(creds Credentials) (*VaultHeader, error) {
+	if creds.Password == "" {
+		return nil, ErrEmptyPassword
+	}
+	header, err := NewVaultHeader(DefaultKDFParams)
+	if err != nil {
+		return nil, err
+	}
+	if creds.KeyFile != "" {
+		hash, err := KeyFileHash(creds.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		header.KeyFile = true
+		header.KeyFileCheck = keyFileCheck(hash, header.Salt)
+	}
+	return header, nil
+}
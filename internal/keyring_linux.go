@@ -0,0 +1,222 @@
+//go:build linux
+
+package internal
+
+import (
+	"encoding/hex"
+	"errors"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	secretServiceName = "org.freedesktop.secrets"
+	secretServicePath = "/org/freedesktop/secrets"
+	secretDefaultPath = "/org/freedesktop/secrets/aliases/default"
+)
+
+var (
+	ErrKeyNotInKeyring = errors.New("vault key not found in keyring")
+	ErrKeyringLocked   = errors.New("keyring is locked")
+)
+
+// secretValue 对应 Secret Service 的 Secret 结构 (oayays)
+type secretValue struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+// KeyringKeyProvider 通过 D-Bus Secret Service (GNOME Keyring、KWallet 等) 保存派生好的密钥。
+// 条目以密码库的 salt 区分，它无法新建密码库，只能取回之前缓存过的密钥
+type KeyringKeyProvider struct {
+	mu        sync.Mutex
+	conn      *dbus.Conn
+	session   dbus.ObjectPath
+	cached    *VaultKey // 已经从密钥环取回或写入过的密钥，避免每次保存都访问 D-Bus
+	forgotten bool
+}
+
+// KeyringSupported 当前平台是否支持系统密钥环
+func KeyringSupported() bool {
+	return true
+}
+
+// NewKeyringKeyProvider 连接会话总线并以明文传输方式打开一个 Secret Service 会话
+func NewKeyringKeyProvider() (KeyProvider, error) {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return nil, err
+	}
+	var output dbus.Variant
+	var session dbus.ObjectPath
+	err = conn.Object(secretServiceName, secretServicePath).
+		Call("org.freedesktop.Secret.Service.OpenSession", 0, "plain", dbus.MakeVariant("")).
+		Store(&output, &session)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyringKeyProvider{conn: conn, session: session}, nil
+}
+
+func keyringAttributes(header *VaultHeader) map[string]string {
+	attrs := map[string]string{"application": "quick-clip"}
+	if header != nil {
+		attrs["vault"] = hex.EncodeToString(header.Salt)
+	}
+	return attrs
+}
+
+// searchItems 返回已解锁的匹配条目；只找到被锁定的条目时返回 ErrKeyringLocked
+func (p *KeyringKeyProvider) searchItems(attrs map[string]string) ([]dbus.ObjectPath, error) {
+	var unlocked, locked []dbus.ObjectPath
+	err := p.conn.Object(secretServiceName, secretServicePath).
+		Call("org.freedesktop.Secret.Service.SearchItems", 0, attrs).
+		Store(&unlocked, &locked)
+	if err != nil {
+		return nil, err
+	}
+	if len(unlocked) == 0 && len(locked) > 0 {
+		return nil, ErrKeyringLocked
+	}
+	return unlocked, nil
+}
+
+func (p *KeyringKeyProvider) ObtainKey(header *VaultHeader) (*VaultKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.forgotten {
+		return nil, ErrVaultLocked
+	}
+	if keyMatches(p.cached, header) || (header == nil && p.cached != nil) {
+		return p.cached, nil
+	}
+	if header == nil {
+		return nil, ErrKeyNotInKeyring
+	}
+	items, err := p.searchItems(keyringAttributes(header))
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, ErrKeyNotInKeyring
+	}
+
+	var secret secretValue
+	err = p.conn.Object(secretServiceName, items[0]).
+		Call("org.freedesktop.Secret.Item.GetSecret", 0, p.session).
+		Store(&secret)
+	if err != nil {
+		return nil, err
+	}
+	p.cached = &VaultKey{Header: header, Key: string(secret.Value)}
+	return p.cached, nil
+}
+
+func (p *KeyringKeyProvider) CacheKey(key *VaultKey) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.forgotten {
+		return ErrVaultLocked
+	}
+	if keyMatches(p.cached, key.Header) && p.cached.Key == key.Key {
+		return nil
+	}
+	if err := p.store(key); err != nil {
+		return err
+	}
+	p.cached = key
+	return nil
+}
+
+// store 以 replace 方式写入 key，同一密码库的旧条目被覆盖
+func (p *KeyringKeyProvider) store(key *VaultKey) error {
+	props := map[string]dbus.Variant{
+		"org.freedesktop.Secret.Item.Label":      dbus.MakeVariant("quick-clip vault key"),
+		"org.freedesktop.Secret.Item.Attributes": dbus.MakeVariant(keyringAttributes(key.Header)),
+	}
+	secret := secretValue{
+		Session:     p.session,
+		Parameters:  []byte{},
+		Value:       []byte(key.Key),
+		ContentType: "application/octet-stream",
+	}
+	var item, prompt dbus.ObjectPath
+	err := p.conn.Object(secretServiceName, secretDefaultPath).
+		Call("org.freedesktop.Secret.Collection.CreateItem", 0, props, secret, true).
+		Store(&item, &prompt)
+	if err != nil {
+		return err
+	}
+	if prompt != "/" {
+		return ErrKeyringLocked
+	}
+	return nil
+}
+
+// ForgetKey 只清除内存中的密钥并关闭会话，密钥环里的条目保留，下次仍可用它解锁
+func (p *KeyringKeyProvider) ForgetKey() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cached = nil
+	if p.forgotten {
+		return nil
+	}
+	p.forgotten = true
+	return p.closeSession()
+}
+
+// closeSession 关闭 NewKeyringKeyProvider 打开的 Secret Service 会话
+func (p *KeyringKeyProvider) closeSession() error {
+	return p.conn.Object(secretServiceName, p.session).
+		Call("org.freedesktop.Secret.Session.Close", 0).Err
+}
+
+// delete 删除 header 对应的密码库在密钥环中的条目，其他密码库的条目不受影响
+func (p *KeyringKeyProvider) delete(header *VaultHeader) error {
+	items, err := p.searchItems(keyringAttributes(header))
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		var prompt dbus.ObjectPath
+		err := p.conn.Object(secretServiceName, item).
+			Call("org.freedesktop.Secret.Item.Delete", 0).
+			Store(&prompt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RememberKeyInKeyring 把解锁用的密钥保存到系统密钥环，之后可以用 NewKeyringKeyProvider 解锁
+func RememberKeyInKeyring(key *VaultKey) error {
+	keys, err := NewKeyringKeyProvider()
+	if err != nil {
+		return err
+	}
+	p := keys.(*KeyringKeyProvider)
+	defer p.ForgetKey()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.store(key)
+}
+
+// ForgetKeyInKeyring 删除 header 对应的密码库保存在系统密钥环中的密钥
+func ForgetKeyInKeyring(header *VaultHeader) error {
+	if header == nil {
+		return ErrNoVaultHeader
+	}
+	keys, err := NewKeyringKeyProvider()
+	if err != nil {
+		return err
+	}
+	p := keys.(*KeyringKeyProvider)
+	defer p.ForgetKey()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.delete(header)
+}
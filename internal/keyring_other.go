@@ -0,0 +1,27 @@
+//go:build !linux
+
+package internal
+
+import "errors"
+
+var ErrKeyringUnsupported = errors.New("keyring is only supported on linux")
+
+// KeyringSupported 当前平台是否支持系统密钥环
+func KeyringSupported() bool {
+	return false
+}
+
+// NewKeyringKeyProvider 系统密钥环目前只支持 Linux 上的 Secret Service
+func NewKeyringKeyProvider() (KeyProvider, error) {
+	return nil, ErrKeyringUnsupported
+}
+
+// RememberKeyInKeyring 系统密钥环目前只支持 Linux
+func RememberKeyInKeyring(key *VaultKey) error {
+	return ErrKeyringUnsupported
+}
+
+// ForgetKeyInKeyring 系统密钥环目前只支持 Linux
+func ForgetKeyInKeyring(header *VaultHeader) error {
+	return ErrKeyringUnsupported
+}
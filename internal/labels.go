@@ -0,0 +1,88 @@
+package internal
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+var ErrInvalidColor = errors.New("invalid colour label")
+
+// Colors 可选的颜色标签，空字符串表示没有颜色
+var Colors = []string{"red", "orange", "yellow", "green", "blue", "purple", "gray"}
+
+// Labels 条目和文件夹共有的整理信息：多对多的标签、收藏和颜色
+type Labels struct {
+	Tags      []string `json:"tags,omitempty"`
+	Favourite bool     `json:"favourite,omitempty"`
+	Color     string   `json:"color,omitempty"`
+}
+
+func (e *Entry) NodeLabels() *Labels  { return &e.Labels }
+func (f *Folder) NodeLabels() *Labels { return &f.Labels }
+
+// normalizeTags 去掉首尾空白和空标签，忽略大小写去重，保留第一次出现的写法和顺序
+func normalizeTags(tags []string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, tag)
+	}
+	return result
+}
+
+// SetLabels 替换节点的标签、收藏和颜色。标签只用于整理，不算修改内容，不更新 Modified，也不产生审计记录
+func (v *Vault) SetLabels(id string, labels Labels) error {
+	node, parent, _ := v.Find(id)
+	if node == nil {
+		return ErrNodeNotFound
+	}
+	if parent == nil {
+		return ErrRootImmutable
+	}
+	if labels.Color != "" && !slices.Contains(Colors, labels.Color) {
+		return ErrInvalidColor
+	}
+	labels.Tags = normalizeTags(labels.Tags)
+	*node.NodeLabels() = labels
+	return nil
+}
+
+// Favourites 按目录树中的顺序列出所有收藏的条目
+func (v *Vault) Favourites() []*Entry {
+	var result []*Entry
+	var walk func(folder *Folder)
+	walk = func(folder *Folder) {
+		for _, child := range folder.Children {
+			switch n := child.(type) {
+			case *Entry:
+				if n.Favourite {
+					result = append(result, n)
+				}
+			case *Folder:
+				walk(n)
+			}
+		}
+	}
+	walk(v.Root)
+	return result
+}
+
+// PrimaryValue 一键复制时使用的值：依次取 password、value 字段，都没有时取第一个字段
+func (e *Entry) PrimaryValue() string {
+	for _, name := range []string{FieldPassword, FieldValue} {
+		if f, ok := e.Field(name); ok {
+			return f.Value
+		}
+	}
+	if len(e.Fields) > 0 {
+		return e.Fields[0].Value
+	}
+	return ""
+}
@@ -0,0 +1,21 @@
+package internal
+
+import "testing"
+
+func TestSetLabelsKeepsModified(t *testing.T) {
+	before, entry := testVault()
+	after, err := before.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := after.SetLabels(entry.ID, Labels{Tags: []string{"work"}, Favourite: true}); err != nil {
+		t.Fatal(err)
+	}
+	got := after.entriesByID()[entry.ID]
+	if !got.Modified.Equal(entry.Modified) {
+		t.Errorf("Modified changed from %v to %v", entry.Modified, got.Modified)
+	}
+	if records := AuditChanges("labels", before, after); len(records) != 0 {
+		t.Errorf("labels produced audit records: %+v", records)
+	}
+}
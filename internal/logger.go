@@ -0,0 +1,384 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	wailslogger "github.com/wailsapp/wails/v2/pkg/logger"
+)
+
+// redacted 替换敏感内容的占位符
+const redacted = "[REDACTED]"
+
+// minSecretLen 短于这个长度的值不做文本替换，否则日志中常见的短字符串都会被遮盖
+const minSecretLen = 4
+
+// sensitiveKeys 属性名包含这些词时整个值被遮盖
+var sensitiveKeys = []string{"password", "passwd", "passphrase", "secret", "token", "credential", "private"}
+
+// sensitiveExactKeys 属性名等于这些词时整个值被遮盖
+var sensitiveExactKeys = []string{"value", "values", "content", "data", "key", "code", "otp"}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range sensitiveKeys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	for _, k := range sensitiveExactKeys {
+		if key == k {
+			return true
+		}
+	}
+	return false
+}
+
+// Redactor 日志脱敏：按属性名遮盖整个值，并把当前密码库中的敏感值从所有文本中替换掉
+type Redactor struct {
+	mu       sync.RWMutex
+	replacer *strings.Replacer // nil 表示没有已知的敏感值
+}
+
+func NewRedactor() *Redactor {
+	return &Redactor{}
+}
+
+// SetSecrets 替换已知的敏感值，values 为空时清空
+func (r *Redactor) SetSecrets(values []string) {
+	unique := make(map[string]bool)
+	for _, v := range values {
+		if len(v) >= minSecretLen {
+			unique[v] = true
+		}
+	}
+	secrets := make([]string, 0, len(unique))
+	for v := range unique {
+		secrets = append(secrets, v)
+	}
+	// 长的先匹配，一个敏感值包含另一个时整个被替换
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+	var replacer *strings.Replacer
+	if len(secrets) > 0 {
+		pairs := make([]string, 0, len(secrets)*2)
+		for _, s := range secrets {
+			pairs = append(pairs, s, redacted)
+		}
+		replacer = strings.NewReplacer(pairs...)
+	}
+	r.mu.Lock()
+	r.replacer = replacer
+	r.mu.Unlock()
+}
+
+// String 替换 s 中所有已知的敏感值
+func (r *Redactor) String(s string) string {
+	r.mu.RLock()
+	replacer := r.replacer
+	r.mu.RUnlock()
+	if replacer == nil {
+		return s
+	}
+	return replacer.Replace(s)
+}
+
+// Attr 返回脱敏后的属性。旧格式内容、字节和 JSON 原文等无法逐项检查的值一律遮盖
+func (r *Redactor) Attr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	if isSensitiveKey(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, r.String(a.Value.String()))
+	case slog.KindGroup:
+		attrs := a.Value.Group()
+		clean := make([]any, len(attrs))
+		for i, attr := range attrs {
+			clean[i] = r.Attr(attr)
+		}
+		return slog.Group(a.Key, clean...)
+	case slog.KindAny:
+		switch v := a.Value.Any().(type) {
+		case error:
+			return slog.String(a.Key, r.String(v.Error()))
+		case []any:
+			return slog.String(a.Key, fmt.Sprintf("%s (%d items)", redacted, len(v)))
+		case map[string]any:
+			return slog.String(a.Key, fmt.Sprintf("%s (%d keys)", redacted, len(v)))
+		case []byte, json.RawMessage:
+			return slog.String(a.Key, redacted)
+		default:
+			// []Field 之类元素自己实现了 LogValue 的切片逐个解析，fmt 不会调用 LogValue
+			if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+				if _, ok := reflect.Zero(rv.Type().Elem()).Interface().(slog.LogValuer); ok {
+					items := make([]any, rv.Len())
+					for i := range items {
+						items[i] = r.Attr(slog.Any(strconv.Itoa(i), rv.Index(i).Interface()))
+					}
+					return slog.Group(a.Key, items...)
+				}
+			}
+			return slog.String(a.Key, r.String(fmt.Sprintf("%+v", v)))
+		}
+	}
+	return a
+}
+
+// redactingHandler 在交给下一个 Handler 之前对消息和所有属性脱敏
+type redactingHandler struct {
+	next     slog.Handler
+	redactor *Redactor
+}
+
+// NewRedactingHandler 包装 next，写出的每条日志都先经过 redactor
+func NewRedactingHandler(next slog.Handler, redactor *Redactor) slog.Handler {
+	return &redactingHandler{next: next, redactor: redactor}
+}
+
+func (h *redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactingHandler) Handle(ctx context.Context, record slog.Record) error {
+	clean := slog.NewRecord(record.Time, record.Level, h.redactor.String(record.Message), record.PC)
+	record.Attrs(func(a slog.Attr) bool {
+		clean.AddAttrs(h.redactor.Attr(a))
+		return true
+	})
+	return h.next.Handle(ctx, clean)
+}
+
+func (h *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clean := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		clean[i] = h.redactor.Attr(a)
+	}
+	return &redactingHandler{next: h.next.WithAttrs(clean), redactor: h.redactor}
+}
+
+func (h *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{next: h.next.WithGroup(name), redactor: h.redactor}
+}
+
+// LogValue 日志中只记录字段名和是否敏感，从不记录值
+func (f Field) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("name", f.Name), slog.Bool("sensitive", f.Sensitive))
+}
+
+// LogValue 日志中只记录条目的 ID、名字和字段个数
+func (e *Entry) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("id", e.ID), slog.String("name", e.Name), slog.Int("fields", len(e.Fields)))
+}
+
+// LogValue 日志中只记录密码库的条目数
+func (v *Vault) LogValue() slog.Value {
+	return slog.GroupValue(slog.Int("entries", len(v.entriesByID())), slog.Int("trash", len(v.Trash)))
+}
+
+// LogValue 主密码和密钥文件路径都不进入日志
+func (c Credentials) LogValue() slog.Value {
+	return slog.GroupValue(slog.Bool("keyFile", c.KeyFile != ""))
+}
+
+// LogValue 验证码只记录类型和标签，不记录密钥
+func (o *OTP) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("type", o.Type), slog.String("label", o.Label()))
+}
+
+// SecretValues 密码库中所有需要从日志中遮盖的值：所有字段的值（旧版本的 value 字段同样可能是密码）、
+// 它们的旧值和验证码密钥
+func (v *Vault) SecretValues() []string {
+	var values []string
+	for _, e := range v.entriesByID() {
+		for _, f := range e.Fields {
+			values = append(values, f.Value)
+		}
+		for _, h := range e.History {
+			values = append(values, h.Value)
+		}
+		if e.OTP != nil {
+			values = append(values, e.OTP.Secret)
+		}
+	}
+	return values
+}
+
+// RotatingFile 按大小滚动的日志文件：超过 maxSize 时 quick-clip.log 改名为 quick-clip.log.1，
+// 更旧的依次后移，最多保留 maxFiles 个旧文件
+type RotatingFile struct {
+	mu       sync.Mutex
+	path     string
+	maxSize  int64
+	maxFiles int
+	file     *os.File
+	size     int64
+}
+
+func OpenRotatingFile(path string, maxSize int64, maxFiles int) (*RotatingFile, error) {
+	f := &RotatingFile{path: path, maxSize: maxSize, maxFiles: maxFiles}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// rotate 关闭当前文件，旧文件依次后移后重新打开，调用时需持有 f.mu
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+	if f.maxFiles > 0 {
+		os.Remove(fmt.Sprintf("%s.%d", f.path, f.maxFiles))
+		for i := f.maxFiles - 1; i >= 1; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", f.path, i), fmt.Sprintf("%s.%d", f.path, i+1))
+		}
+		os.Rename(f.path, f.path+".1")
+	} else {
+		os.Remove(f.path)
+	}
+	// 改名失败（例如文件被其它程序占用）时继续追加到原来的文件
+	return f.open()
+}
+
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// Logger 同时写到标准输出和滚动日志文件的 slog 日志，所有记录先经过 Redactor 脱敏
+type Logger struct {
+	*slog.Logger
+	level    *slog.LevelVar
+	redactor *Redactor
+	file     *RotatingFile // 日志文件打不开时为 nil
+}
+
+// DefaultLogDir 日志文件所在的目录
+func DefaultLogDir() string {
+	configDir, _ := os.UserConfigDir()
+	return filepath.Join(configDir, "quick-clip", "logs")
+}
+
+// NewLogger 在 dir 下创建 quick-clip.log。日志文件打不开时只写标准输出，错误照常返回
+func NewLogger(dir string, cfg LogConfig) (*Logger, error) {
+	l := &Logger{level: new(slog.LevelVar), redactor: NewRedactor()}
+	l.SetLevel(cfg.Level)
+
+	var out io.Writer = os.Stdout
+	err := os.MkdirAll(dir, 0700)
+	if err == nil {
+		l.file, err = OpenRotatingFile(filepath.Join(dir, "quick-clip.log"), int64(cfg.MaxSizeMB)<<20, cfg.MaxFiles)
+	}
+	if err == nil {
+		// 文件在前：没有控制台的窗口程序写标准输出会失败，不能影响文件
+		out = io.MultiWriter(l.file, os.Stdout)
+	}
+	handler := slog.NewTextHandler(out, &slog.HandlerOptions{Level: l.level})
+	l.Logger = slog.New(NewRedactingHandler(handler, l.redactor))
+	return l, err
+}
+
+// SetLevel 修改日志级别（debug、info、warn、error），无法识别时使用 info
+func (l *Logger) SetLevel(level string) {
+	var parsed slog.Level
+	if err := parsed.UnmarshalText([]byte(level)); err != nil {
+		parsed = slog.LevelInfo
+	}
+	l.level.Set(parsed)
+}
+
+// SetSecrets 从 vault 中取出需要遮盖的值，vault 为 nil（锁定）时清空
+func (l *Logger) SetSecrets(vault *Vault) {
+	if vault == nil {
+		l.redactor.SetSecrets(nil)
+		return
+	}
+	l.redactor.SetSecrets(vault.SecretValues())
+}
+
+func (l *Logger) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Close()
+}
+
+// Wails 把 Wails 运行时和前端 LogInfo 等的日志也接到同一个 Logger，同样经过脱敏。
+// Wails 的 Trace 日志包含绑定方法调用的参数和返回的 JSON，一律丢弃
+func (l *Logger) Wails() wailslogger.Logger {
+	return wailsLogger{l.Logger}
+}
+
+type wailsLogger struct {
+	log *slog.Logger
+}
+
+// callArgs Wails 出错时会把原始的调用消息写进日志，其中的参数可能是主密码
+const callArgs = `"args":`
+
+// redactCallArgs 遮盖调用消息中 "args" 之后的全部内容
+func redactCallArgs(message string) string {
+	if i := strings.Index(message, callArgs); i >= 0 {
+		return message[:i+len(callArgs)] + redacted
+	}
+	return message
+}
+
+func (w wailsLogger) Print(message string)   { w.log.Info(redactCallArgs(message)) }
+func (w wailsLogger) Trace(message string)   {}
+func (w wailsLogger) Debug(message string)   { w.log.Debug(redactCallArgs(message)) }
+func (w wailsLogger) Info(message string)    { w.log.Info(redactCallArgs(message)) }
+func (w wailsLogger) Warning(message string) { w.log.Warn(redactCallArgs(message)) }
+func (w wailsLogger) Error(message string)   { w.log.Error(redactCallArgs(message)) }
+func (w wailsLogger) Fatal(message string) {
+	w.log.Error(redactCallArgs(message))
+	os.Exit(1)
+}
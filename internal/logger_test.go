@@ -0,0 +1,182 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	testPassword   = "correct-horse-battery-staple"
+	testToken      = "tok_9f8e7d6c5b4a"
+	testOldSecret  = "previous-password-2019"
+	testOTPSecret  = "JBSWY3DPEHPK3PXP"
+	testCredential = "master-password-unknown-to-vault"
+)
+
+func testVault() (*Vault, *Entry) {
+	v := NewVault()
+	entry := NewFieldsEntry("github", []Field{
+		{Name: FieldPassword, Value: testPassword},
+		{Name: "username", Value: "octocat"},
+		{Name: "api", Value: testToken, Sensitive: true},
+	})
+	entry.History = []FieldVersion{{Field: Field{Name: FieldPassword, Value: testOldSecret}}}
+	entry.OTP = &OTP{Type: OTPTypeTOTP, Secret: testOTPSecret, Issuer: "GitHub", Account: "octocat"}
+	v.Root.Children = append(v.Root.Children, entry)
+	return v, entry
+}
+
+func assertNoSecrets(t *testing.T, output string, secrets ...string) {
+	t.Helper()
+	for _, s := range secrets {
+		if strings.Contains(output, s) {
+			t.Errorf("log output contains secret %q:\n%s", s, output)
+		}
+	}
+}
+
+func TestRedactingHandler(t *testing.T) {
+	vault, entry := testVault()
+	redactor := NewRedactor()
+	redactor.SetSecrets(vault.SecretValues())
+	var buf bytes.Buffer
+	log := slog.New(NewRedactingHandler(slog.NewTextHandler(&buf, nil), redactor))
+
+	log.Info("copied " + testPassword)
+	log.Info("entry", "entry", entry, "fields", entry.Fields, "otp", entry.OTP)
+	log.Info("vault", "vault", vault, "history", entry.History[0])
+	log.Warn("save failed", "err", fmt.Errorf("write %s: %w", testToken, errors.New("disk full")))
+	log.Info("legacy", "items", vault.ToLegacy(), "raw", []byte(testPassword))
+	log.Info("nested", slog.Group("req", slog.String("header", "Bearer "+testToken)))
+	log.With("note", "otp "+testOTPSecret).WithGroup("g").Info("with", "old", testOldSecret)
+	log.Info("login", "password", testCredential, "creds", Credentials{Password: testCredential})
+
+	output := buf.String()
+	assertNoSecrets(t, output, testPassword, testToken, testOldSecret, testOTPSecret, testCredential)
+	if !strings.Contains(output, redacted) {
+		t.Errorf("expected %q in output:\n%s", redacted, output)
+	}
+	// 非敏感的内容照常记录
+	if !strings.Contains(output, "github") || !strings.Contains(output, "disk full") {
+		t.Errorf("non-secret values should be kept:\n%s", output)
+	}
+}
+
+func TestRedactingHandlerAfterLock(t *testing.T) {
+	vault, _ := testVault()
+	redactor := NewRedactor()
+	redactor.SetSecrets(vault.SecretValues())
+	redactor.SetSecrets(nil)
+	var buf bytes.Buffer
+	log := slog.New(NewRedactingHandler(slog.NewTextHandler(&buf, nil), redactor))
+
+	// 锁定后不再知道具体的值，但旧格式内容和敏感属性名仍然被遮盖
+	entry := vault.Root.Children[0].(*Entry)
+	log.Info("import", "items", vault.ToLegacy(), "secret", testOTPSecret, "content", testPassword)
+	log.Info("fields", "fields", entry.Fields, "history", entry.History, "entries", []*Entry{entry})
+	assertNoSecrets(t, buf.String(), testPassword, testToken, testOldSecret, testOTPSecret)
+}
+
+func TestRedactingHandlerLegacyValue(t *testing.T) {
+	vault, err := VaultFromLegacy([]any{
+		map[string]any{"mail": testPassword},
+		map[string]any{"work": []any{map[string]any{"vpn": testToken}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	redactor := NewRedactor()
+	redactor.SetSecrets(vault.SecretValues())
+	var buf bytes.Buffer
+	log := slog.New(NewRedactingHandler(slog.NewTextHandler(&buf, nil), redactor))
+
+	// 旧版本的 value 字段不是 Sensitive，同样要遮盖
+	log.Debug("json call result data: " + `{"fields":[{"name":"value","value":"` + testPassword + `","sensitive":false}]}`)
+	log.Info("vault", "vault", vault, "result", testToken)
+	output := buf.String()
+	assertNoSecrets(t, output, testPassword, testToken)
+	if !strings.Contains(output, "entries=2") {
+		t.Errorf("non-secret values should be kept:\n%s", output)
+	}
+}
+
+func TestWailsLoggerCallArgs(t *testing.T) {
+	dir := t.TempDir()
+	logger, err := NewLogger(dir, LogConfig{Level: "debug", MaxSizeMB: 1, MaxFiles: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wails := logger.Wails()
+	wails.Trace("json call result data: " + testToken)
+	wails.Error(`process message error: C{"name":"main.App.Unlock","args":[{"password":"` + testCredential + `"}],"callbackID":"1"} -> panic`)
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "quick-clip.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "main.App.Unlock") {
+		t.Fatalf("log file is missing the error:\n%s", data)
+	}
+	assertNoSecrets(t, string(data), testToken, testCredential)
+}
+
+func TestLoggerFile(t *testing.T) {
+	dir := t.TempDir()
+	logger, err := NewLogger(dir, LogConfig{Level: "debug", MaxSizeMB: 1, MaxFiles: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vault, entry := testVault()
+	logger.SetSecrets(vault)
+	logger.Debug("paste", "entry", entry, "value", testPassword)
+	logger.Wails().Info("frontend: " + testToken)
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "quick-clip.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "paste") || !strings.Contains(string(data), "frontend") {
+		t.Fatalf("log file is missing records:\n%s", data)
+	}
+	assertNoSecrets(t, string(data), testPassword, testToken)
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := OpenRotatingFile(path, 100, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := []byte(strings.Repeat("x", 39) + "\n")
+	for i := 0; i < 20; i++ {
+		if _, err := f.Write(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() > 100 {
+			t.Errorf("%s is %d bytes, want at most 100", name, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected at most 2 rotated files, got %v", err)
+	}
+}
@@ -0,0 +1,454 @@
+package internal
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OTP 类型和算法
+const (
+	OTPTypeTOTP = "totp" // RFC 6238，按时间
+	OTPTypeHOTP = "hotp" // RFC 4226，按计数器
+
+	OTPSHA1   = "SHA1"
+	OTPSHA256 = "SHA256"
+	OTPSHA512 = "SHA512"
+)
+
+var (
+	ErrNoOTP      = errors.New("entry has no one-time code")
+	ErrInvalidOTP = errors.New("invalid one-time code settings")
+	ErrOTPURI     = errors.New("invalid otpauth URI")
+)
+
+// OTP 条目的一次性验证码设置。Secret 为 base32 编码的种子，随密码库一起加密
+type OTP struct {
+	Type      string `json:"type"`
+	Secret    string `json:"secret"`
+	Algorithm string `json:"algorithm"`
+	Digits    int    `json:"digits"`
+	Period    int    `json:"period,omitempty"`  // TOTP 的时间步长（秒）
+	Counter   uint64 `json:"counter,omitempty"` // HOTP 下一次使用的计数器
+	Issuer    string `json:"issuer,omitempty"`
+	Account   string `json:"account,omitempty"`
+}
+
+// normalize 补全默认值，种子统一为去掉空白和填充的大写 base32
+func (o *OTP) normalize() {
+	o.Type = strings.ToLower(o.Type)
+	if o.Type == "" {
+		o.Type = OTPTypeTOTP
+	}
+	o.Algorithm = strings.ToUpper(strings.ReplaceAll(o.Algorithm, "-", ""))
+	if o.Algorithm == "" {
+		o.Algorithm = OTPSHA1
+	}
+	if o.Digits == 0 {
+		o.Digits = 6
+	}
+	if o.Type == OTPTypeTOTP && o.Period == 0 {
+		o.Period = 30
+	}
+	o.Secret = strings.TrimRight(strings.ToUpper(strings.Join(strings.Fields(o.Secret), "")), "=")
+}
+
+func (o OTP) key() ([]byte, error) {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(o.Secret)
+	if err != nil || len(key) == 0 {
+		return nil, fmt.Errorf("%w: secret is not base32", ErrInvalidOTP)
+	}
+	return key, nil
+}
+
+func (o OTP) hash() (func() hash.Hash, error) {
+	switch o.Algorithm {
+	case OTPSHA1:
+		return sha1.New, nil
+	case OTPSHA256:
+		return sha256.New, nil
+	case OTPSHA512:
+		return sha512.New, nil
+	}
+	return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidOTP, o.Algorithm)
+}
+
+// Validate 检查设置是否能生成验证码
+func (o OTP) Validate() error {
+	if o.Type != OTPTypeTOTP && o.Type != OTPTypeHOTP {
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidOTP, o.Type)
+	}
+	if _, err := o.key(); err != nil {
+		return err
+	}
+	if _, err := o.hash(); err != nil {
+		return err
+	}
+	if o.Digits < 6 || o.Digits > 8 {
+		return fmt.Errorf("%w: digits must be between 6 and 8", ErrInvalidOTP)
+	}
+	if o.Type == OTPTypeTOTP && (o.Period < 1 || o.Period > 300) {
+		return fmt.Errorf("%w: period must be between 1 and 300 seconds", ErrInvalidOTP)
+	}
+	return nil
+}
+
+// hotp RFC 4226 的 HMAC 动态截断
+func (o OTP) hotp(counter uint64) (string, error) {
+	key, err := o.key()
+	if err != nil {
+		return "", err
+	}
+	newHash, err := o.hash()
+	if err != nil {
+		return "", err
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(newHash, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	bin := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for range o.Digits {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", o.Digits, bin%mod), nil
+}
+
+// Code 当前的验证码：TOTP 按 now 计算，HOTP 使用 Counter（不递增）
+func (o OTP) Code(now time.Time) (string, error) {
+	if err := o.Validate(); err != nil {
+		return "", err
+	}
+	if o.Type == OTPTypeHOTP {
+		return o.hotp(o.Counter)
+	}
+	return o.hotp(uint64(now.Unix()) / uint64(o.Period))
+}
+
+// Remaining TOTP 当前验证码还有多少秒失效，HOTP 返回 0
+func (o OTP) Remaining(now time.Time) int {
+	if o.Type != OTPTypeTOTP || o.Period <= 0 {
+		return 0
+	}
+	return o.Period - int(now.Unix()%int64(o.Period))
+}
+
+// Label 由发行方和账号组成的名字，用作导入条目的名字
+func (o OTP) Label() string {
+	switch {
+	case o.Issuer != "" && o.Account != "":
+		return o.Issuer + " (" + o.Account + ")"
+	case o.Issuer != "":
+		return o.Issuer
+	case o.Account != "":
+		return o.Account
+	}
+	return "OTP"
+}
+
+// URI 转换为 otpauth:// URI，供导出使用
+func (o OTP) URI() string {
+	label := o.Account
+	if o.Issuer != "" {
+		label = o.Issuer + ":" + o.Account
+	}
+	q := url.Values{}
+	q.Set("secret", o.Secret)
+	if o.Issuer != "" {
+		q.Set("issuer", o.Issuer)
+	}
+	q.Set("algorithm", o.Algorithm)
+	q.Set("digits", strconv.Itoa(o.Digits))
+	if o.Type == OTPTypeHOTP {
+		q.Set("counter", strconv.FormatUint(o.Counter, 10))
+	} else {
+		q.Set("period", strconv.Itoa(o.Period))
+	}
+	u := url.URL{Scheme: "otpauth", Host: o.Type, Path: "/" + label, RawQuery: q.Encode()}
+	return u.String()
+}
+
+// ParseOTPURI 解析 otpauth://TYPE/ISSUER:ACCOUNT?secret=...&issuer=...&algorithm=...&digits=...&period=...&counter=...
+func ParseOTPURI(raw string) (*OTP, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Scheme != "otpauth" {
+		return nil, ErrOTPURI
+	}
+	q := u.Query()
+	o := &OTP{Type: u.Host, Secret: q.Get("secret"), Algorithm: q.Get("algorithm")}
+	label := strings.TrimPrefix(u.Path, "/")
+	if issuer, account, ok := strings.Cut(label, ":"); ok {
+		o.Issuer, o.Account = strings.TrimSpace(issuer), strings.TrimSpace(account)
+	} else {
+		o.Account = strings.TrimSpace(label)
+	}
+	if issuer := q.Get("issuer"); issuer != "" {
+		o.Issuer = issuer
+	}
+	for name, target := range map[string]*int{"digits": &o.Digits, "period": &o.Period} {
+		if s := q.Get(name); s != "" {
+			if *target, err = strconv.Atoi(s); err != nil {
+				return nil, fmt.Errorf("%w: %s=%q", ErrOTPURI, name, s)
+			}
+		}
+	}
+	if s := q.Get("counter"); s != "" {
+		if o.Counter, err = strconv.ParseUint(s, 10, 64); err != nil {
+			return nil, fmt.Errorf("%w: counter=%q", ErrOTPURI, s)
+		}
+	}
+	o.normalize()
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// ParseMigrationURI 解析 Google Authenticator 导出的 otpauth-migration://offline?data=...，
+// data 是 base64 编码的 protobuf MigrationPayload
+func ParseMigrationURI(raw string) ([]*OTP, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Scheme != "otpauth-migration" {
+		return nil, ErrOTPURI
+	}
+	data := strings.TrimRight(u.Query().Get("data"), "=")
+	payload, err := base64.RawStdEncoding.DecodeString(data)
+	if err != nil {
+		if payload, err = base64.RawURLEncoding.DecodeString(data); err != nil {
+			return nil, fmt.Errorf("%w: data is not base64", ErrOTPURI)
+		}
+	}
+	var result []*OTP
+	err = readProto(payload, func(num int, _ uint64, msg []byte) error {
+		if num != 1 || msg == nil { // repeated OtpParameters otp_parameters = 1
+			return nil
+		}
+		o, err := migrationParameters(msg)
+		if err == nil {
+			result = append(result, o)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// migrationParameters 解析 OtpParameters：secret=1 name=2 issuer=3 algorithm=4 digits=5 type=6 counter=7
+func migrationParameters(msg []byte) (*OTP, error) {
+	var o OTP
+	var secret []byte
+	err := readProto(msg, func(num int, n uint64, b []byte) error {
+		switch num {
+		case 1:
+			secret = b
+		case 2:
+			o.Account = string(b)
+		case 3:
+			o.Issuer = string(b)
+		case 4:
+			switch n {
+			case 0, 1:
+				o.Algorithm = OTPSHA1
+			case 2:
+				o.Algorithm = OTPSHA256
+			case 3:
+				o.Algorithm = OTPSHA512
+			default:
+				return fmt.Errorf("%w: unsupported algorithm %d", ErrInvalidOTP, n)
+			}
+		case 5:
+			if n == 2 {
+				o.Digits = 8
+			}
+		case 6:
+			if n == 1 {
+				o.Type = OTPTypeHOTP
+			}
+		case 7:
+			o.Counter = n
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// name 通常是 "发行方:账号"
+	if issuer, account, ok := strings.Cut(o.Account, ":"); ok && (o.Issuer == "" || o.Issuer == issuer) {
+		o.Issuer, o.Account = issuer, account
+	}
+	o.Secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(secret)
+	o.normalize()
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// readProto 依次读取 protobuf 消息中的字段，varint 字段传入 n，length-delimited 字段传入 b，
+// 其它类型跳过
+func readProto(data []byte, fn func(num int, n uint64, b []byte) error) error {
+	malformed := fmt.Errorf("%w: malformed migration payload", ErrOTPURI)
+	for len(data) > 0 {
+		tag, k := binary.Uvarint(data)
+		if k <= 0 {
+			return malformed
+		}
+		data = data[k:]
+		num := int(tag >> 3)
+		switch tag & 7 {
+		case 0:
+			n, k := binary.Uvarint(data)
+			if k <= 0 {
+				return malformed
+			}
+			data = data[k:]
+			if err := fn(num, n, nil); err != nil {
+				return err
+			}
+		case 2:
+			size, k := binary.Uvarint(data)
+			if k <= 0 || uint64(len(data)-k) < size {
+				return malformed
+			}
+			b := data[k : k+int(size)]
+			data = data[k+int(size):]
+			if err := fn(num, 0, b); err != nil {
+				return err
+			}
+		case 1:
+			if len(data) < 8 {
+				return malformed
+			}
+			data = data[8:]
+		case 5:
+			if len(data) < 4 {
+				return malformed
+			}
+			data = data[4:]
+		default:
+			return malformed
+		}
+	}
+	return nil
+}
+
+// ParseOTPImport 解析粘贴的文本，每行一个 otpauth:// 或 otpauth-migration:// URI，空行忽略
+func ParseOTPImport(text string) ([]*OTP, error) {
+	var result []*OTP
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "otpauth-migration:"):
+			items, err := ParseMigrationURI(line)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, items...)
+		default:
+			o, err := ParseOTPURI(line)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, o)
+		}
+	}
+	if len(result) == 0 {
+		return nil, ErrOTPURI
+	}
+	return result, nil
+}
+
+// SetOTP 设置条目的一次性验证码，otp 为 nil 表示移除
+func (v *Vault) SetOTP(id string, otp *OTP) error {
+	entry, err := v.entry(id)
+	if err != nil {
+		return err
+	}
+	if otp != nil {
+		otp.normalize()
+		if err := otp.Validate(); err != nil {
+			return err
+		}
+	}
+	entry.OTP = otp
+	entry.Modified = time.Now()
+	return nil
+}
+
+// NextOTP 返回条目当前的验证码。HOTP 条目同时递增计数器，调用方需要保存密码库
+func (v *Vault) NextOTP(id string, now time.Time) (string, error) {
+	entry, err := v.entry(id)
+	if err != nil {
+		return "", err
+	}
+	if entry.OTP == nil {
+		return "", ErrNoOTP
+	}
+	code, err := entry.OTP.Code(now)
+	if err != nil {
+		return "", err
+	}
+	if entry.OTP.Type == OTPTypeHOTP {
+		entry.OTP.Counter++
+	}
+	return code, nil
+}
+
+// KeepOTPCounters 撤销、重做、恢复备份或导入时沿用 current 中更大的 HOTP 计数器，
+// 已经用过的验证码不会再次生成。有计数器被调高时返回 true
+func (v *Vault) KeepOTPCounters(current *Vault) bool {
+	counters := current.entriesByID()
+	changed := false
+	for id, e := range v.entriesByID() {
+		if e.OTP == nil || e.OTP.Type != OTPTypeHOTP {
+			continue
+		}
+		if c, ok := counters[id]; ok && c.OTP != nil && c.OTP.Type == OTPTypeHOTP && c.OTP.Counter > e.OTP.Counter {
+			e.OTP.Counter = c.OTP.Counter
+			changed = true
+		}
+	}
+	return changed
+}
+
+// ImportOTP 在 parentID 下为每个解析出的验证码新建一个只有 OTP 的条目，重名时自动加序号
+func (v *Vault) ImportOTP(parentID string, text string) ([]*Entry, error) {
+	parent, err := v.folder(parentID)
+	if err != nil {
+		return nil, err
+	}
+	otps, err := ParseOTPImport(text)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*Entry, 0, len(otps))
+	for _, otp := range otps {
+		entry := NewFieldsEntry(uniqueName(parent, otp.Label()), []Field{})
+		if otp.Account != "" {
+			entry.Fields = append(entry.Fields, Field{Name: FieldUsername, Value: otp.Account})
+		}
+		entry.OTP = otp
+		parent.Children = append(parent.Children, entry)
+		parent.Modified = entry.Created
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
@@ -0,0 +1,38 @@
+package internal
+
+import "testing"
+
+func TestImportKeepsHOTPCounter(t *testing.T) {
+	current := NewVault()
+	entry := NewFieldsEntry("bank", []Field{})
+	entry.OTP = &OTP{Type: OTPTypeHOTP, Secret: testOTPSecret, Algorithm: "SHA1", Digits: 6, Counter: 5}
+	current.Root.Children = append(current.Root.Children, entry)
+
+	// 较早的导出文件中计数器还是 2
+	older, err := current.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	older.entriesByID()[entry.ID].OTP.Counter = 2
+	export := older.ToLegacy()
+
+	imported, err := current.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := imported.ApplyLegacy(export); err != nil {
+		t.Fatal(err)
+	}
+	if got := imported.entriesByID()[entry.ID].OTP.Counter; got != 2 {
+		t.Fatalf("imported counter = %d, want 2 before KeepOTPCounters", got)
+	}
+	if !imported.KeepOTPCounters(current) {
+		t.Error("KeepOTPCounters should report the raised counter")
+	}
+	if got := imported.entriesByID()[entry.ID].OTP.Counter; got != 5 {
+		t.Errorf("counter = %d, want 5", got)
+	}
+	if current.entriesByID()[entry.ID].OTP.Counter != 5 {
+		t.Error("current vault was modified")
+	}
+}
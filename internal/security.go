@@ -3,17 +3,72 @@ package internal
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
 	"encoding/base64"
-	"encoding/json"
 	"errors"
-	"fmt"
 	"io"
-	"os"
 
-	jsoniter "github.com/json-iterator/go"
+	"golang.org/x/crypto/argon2"
 )
 
+// LegacyVaultKey 旧版本硬编码的密钥，仅用于迁移没有文件头的旧密码库
+const LegacyVaultKey = "11112222111122221111222211112222"
+
+var (
+	ErrWrongPassword = errors.New("wrong master password")
+	ErrEmptyPassword = errors.New("master password must not be empty")
+	ErrVaultLocked   = errors.New("vault is locked")
+)
+
+// KDFParams Argon2id 的代价参数，随密码库一起保存，便于以后调高
+type KDFParams struct {
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"` // KiB
+	Threads uint8  `json:"threads"`
+}
+
+// DefaultKDFParams 新建密码库时使用的默认参数
+var DefaultKDFParams = KDFParams{
+	Time:    3,
+	Memory:  64 * 1024,
+	Threads: 4,
+}
+
+// DeriveKey 使用 Argon2id 从主密码（及密钥文件）组合出的 secret 派生 32 字节密钥
+func DeriveKey(secret []byte, salt []byte, params KDFParams) string {
+	return string(argon2.IDKey(secret, salt, params.Time, params.Memory, params.Threads, 32))
+}
+
+// VaultKey 主密码派生出的密钥以及派生它所用的文件头
+type VaultKey struct {
+	Header *VaultHeader
+	Key    string
+}
+
+// NewVaultKey 根据文件头和凭据派生密钥。
+// 文件头要求密钥文件时，缺少或不匹配分别返回 ErrKeyFileRequired 和 ErrWrongKeyFile
+func NewVaultKey(creds Credentials, header *VaultHeader) (*VaultKey, error) {
+	var keyFileHash []byte
+	if header.KeyFile {
+		if creds.KeyFile == "" {
+			return nil, ErrKeyFileRequired
+		}
+		hash, err := KeyFileHash(creds.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if !hmac.Equal(keyFileCheck(hash, header.Salt), header.KeyFileCheck) {
+			return nil, ErrWrongKeyFile
+		}
+		keyFileHash = hash
+	}
+	return &VaultKey{
+		Header: header,
+		Key:    DeriveKey(compositeKey(creds.Password, keyFileHash), header.Salt, header.KDF),
+	}, nil
+}
+
 // Encrypt encrypts plaintext using AES-256-CBC with a random IV.
 // The IV is prepended to the ciphertext and the whole is base64 encoded.
 // Key should be 32 bytes.
@@ -108,7 +163,7 @@ func Decrypt(ciphertext, key string) (string, error) {
 	iv := data[:aes.BlockSize]
 	ciphertextBytes := data[aes.BlockSize:]
 
-	if len(ciphertextBytes)%aes.BlockSize != 0 {
+	if len(ciphertextBytes) == 0 || len(ciphertextBytes)%aes.BlockSize != 0 {
 		return "", errors.New("ciphertext is not a multiple of the block size")
 	}
 
@@ -151,7 +206,7 @@ func DecryptBytes(ciphertext []byte, key string) ([]byte, error) {
 	iv := data[:aes.BlockSize]
 	ciphertextBytes := data[aes.BlockSize:]
 
-	if len(ciphertextBytes)%aes.BlockSize != 0 {
+	if len(ciphertextBytes) == 0 || len(ciphertextBytes)%aes.BlockSize != 0 {
 		return nil, errors.New("ciphertext is not a multiple of the block size")
 	}
 
@@ -171,81 +226,3 @@ func DecryptBytes(ciphertext []byte, key string) ([]byte, error) {
 
 	return ciphertextBytes[:len(ciphertextBytes)-padding], nil
 }
-
-func ReadContent(path string, keys string) []any {
-	content, err := os.ReadFile(path)
-	// 情况 A: 文件不存在，初始化并返回空数据
-	if os.IsNotExist(err) {
-		initialData := []byte("[]")
-		// 将加密后的初始数据保存到磁盘，方便下次读取
-		SaveContentBytes(path, keys, initialData)
-		return []any{}
-	} else if err != nil {
-		fmt.Printf("读取文件失败: %v\n", err)
-		return nil
-	}
-
-	// 情况 B: 文件存在，解密
-	decrypted, err := DecryptBytes(content, keys)
-	if err != nil {
-		fmt.Printf("解密失败 (可能是密钥不匹配或文件损坏): %v\n", err)
-		return nil
-	}
-
-	var json = jsoniter.ConfigCompatibleWithStandardLibrary
-	var tContent []any
-	err = json.Unmarshal(decrypted, &tContent)
-	if err != nil {
-		fmt.Printf("JSON解析失败: %v\n", err)
-		return nil
-	}
-	return tContent
-}
-
-func ReadContenttoBytes(path string, keys string) []byte {
-	content, err := os.ReadFile(path)
-	if os.IsNotExist(err) {
-		// 文件不存在，创建文件并写入初始数据
-		initialData := []byte("[]") // 空的JSON数组
-		resource, err := EncryptBytes(initialData, keys)
-		if err != nil {
-			fmt.Println(err)
-			return nil
-		}
-		err = os.WriteFile(path, resource, 0644)
-		if err != nil {
-			fmt.Println(err)
-			return nil
-		}
-		content = initialData // 使用刚创建的初始数据
-	} else if err != nil {
-		// 其他读取错误
-		fmt.Println(err)
-		return nil
-	}
-
-	decrypted, err := DecryptBytes(content, keys)
-	if err != nil {
-		fmt.Println(err)
-		return nil
-	}
-	return decrypted
-}
-
-// 辅助函数：直接保存字节流
-func SaveContentBytes(path string, keys string, byteData []byte) {
-	resource, err := EncryptBytes(byteData, keys)
-	if err != nil {
-		return
-	}
-	_ = os.WriteFile(path, resource, 0644)
-}
-
-// 你的 SaveContent 也可以简化调用这个辅助函数
-func SaveContent(path string, keys string, content []any) {
-	byteData, err := json.Marshal(content)
-	if err != nil || byteData == nil {
-		return
-	}
-	SaveContentBytes(path, keys, byteData)
-}
@@ -0,0 +1,160 @@
+package internal
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ccojocar/zxcvbn-go"
+)
+
+// WeakScore zxcvbn 评分低于此值（0-4）的密码视为弱密码
+const WeakScore = 3
+
+// ReportRef 报告中引用的一个条目字段
+type ReportRef struct {
+	ID    string   `json:"id"`
+	Name  string   `json:"name"`
+	Path  []string `json:"path"` // 所在文件夹从根开始的名字
+	Field string   `json:"field"`
+}
+
+// StrengthMatch 强度估算中识别出的一段模式，不包含原文
+type StrengthMatch struct {
+	Pattern    string `json:"pattern"`    // dictionary、spatial、repeat、sequence、date、bruteforce 等
+	Dictionary string `json:"dictionary"` // pattern 为 dictionary 时的词典名
+	Length     int    `json:"length"`     // 匹配的字符数
+}
+
+// StrengthItem 一个密码的强度估算
+type StrengthItem struct {
+	ReportRef
+	Score     int             `json:"score"`     // 0-4
+	Entropy   float64         `json:"entropy"`   // 比特
+	CrackTime string          `json:"crackTime"` // 估算的破解时间，如 "3 hours"
+	Weak      bool            `json:"weak"`
+	Matches   []StrengthMatch `json:"matches"`
+}
+
+// AgeItem 长时间没有修改过的密码
+type AgeItem struct {
+	ReportRef
+	Changed time.Time `json:"changed"` // 当前值的设置时间
+}
+
+// SecurityReport 密码库健康报告，检查目录树中的敏感字段、password 和 value 字段（不含回收站），完全离线计算
+type SecurityReport struct {
+	Generated time.Time      `json:"generated"`
+	Checked   int            `json:"checked"`  // 检查的字段数
+	Strength  []StrengthItem `json:"strength"` // 按评分从低到高
+	Reused    [][]ReportRef  `json:"reused"`   // 值相同的字段分组
+	Old       []AgeItem      `json:"old"`      // 超过 oldAfter 没有修改，按时间从旧到新
+	Empty     []ReportRef    `json:"empty"`
+}
+
+// isSecret 字段是否作为密码检查，旧版本和简单条目的 value 字段也可能保存密码，一并检查
+func (f Field) isSecret() bool {
+	return f.Sensitive || f.Name == FieldPassword || f.Name == FieldValue
+}
+
+// valueChanged 字段当前值的设置时间：历史中该字段最近一次被替换的时间，没有历史时为条目创建时间
+func (e *Entry) valueChanged(field string) time.Time {
+	changed := e.Created
+	for _, h := range e.History {
+		if h.Name == field && h.Changed.After(changed) {
+			changed = h.Changed
+		}
+	}
+	return changed
+}
+
+// estimateStrength 用 zxcvbn 估算强度，条目名、所在文件夹名和非敏感字段的值作为用户相关词典
+func estimateStrength(value string, userInputs []string) StrengthItem {
+	result := zxcvbn.PasswordStrength(value, userInputs)
+	item := StrengthItem{
+		Score:     result.Score,
+		Entropy:   result.Entropy,
+		CrackTime: result.CrackTimeDisplay,
+		Weak:      result.Score < WeakScore,
+		Matches:   []StrengthMatch{},
+	}
+	for _, m := range result.MatchSequence {
+		item.Matches = append(item.Matches, StrengthMatch{
+			Pattern:    m.Pattern,
+			Dictionary: m.DictionaryName,
+			Length:     m.J - m.I + 1,
+		})
+	}
+	return item
+}
+
+// SecurityReport 生成健康报告：强度估算、重复使用、oldAfter 以上未修改和空值
+func (v *Vault) SecurityReport(now time.Time, oldAfter time.Duration) *SecurityReport {
+	report := &SecurityReport{
+		Generated: now,
+		Strength:  []StrengthItem{},
+		Reused:    [][]ReportRef{},
+		Old:       []AgeItem{},
+		Empty:     []ReportRef{},
+	}
+	groups := make(map[string][]ReportRef)
+	var order []string
+
+	var walk func(folder *Folder)
+	walk = func(folder *Folder) {
+		for _, child := range folder.Children {
+			switch n := child.(type) {
+			case *Entry:
+				path := v.pathTo(folder)
+				userInputs := append([]string{n.Name}, path...)
+				for _, f := range n.Fields {
+					if !f.isSecret() {
+						userInputs = append(userInputs, f.Value)
+					}
+				}
+				for _, f := range n.Fields {
+					if !f.isSecret() {
+						continue
+					}
+					report.Checked++
+					ref := ReportRef{ID: n.ID, Name: n.Name, Path: path, Field: f.Name}
+					if f.Value == "" {
+						report.Empty = append(report.Empty, ref)
+						continue
+					}
+					item := estimateStrength(f.Value, userInputs)
+					item.ReportRef = ref
+					report.Strength = append(report.Strength, item)
+
+					if _, ok := groups[f.Value]; !ok {
+						order = append(order, f.Value)
+					}
+					groups[f.Value] = append(groups[f.Value], ref)
+
+					if changed := n.valueChanged(f.Name); oldAfter > 0 && now.Sub(changed) > oldAfter {
+						report.Old = append(report.Old, AgeItem{ReportRef: ref, Changed: changed})
+					}
+				}
+			case *Folder:
+				walk(n)
+			}
+		}
+	}
+	walk(v.Root)
+
+	for _, value := range order {
+		if len(groups[value]) > 1 {
+			report.Reused = append(report.Reused, groups[value])
+		}
+	}
+	sort.SliceStable(report.Strength, func(i, j int) bool {
+		a, b := report.Strength[i], report.Strength[j]
+		if a.Score != b.Score {
+			return a.Score < b.Score
+		}
+		return a.Entropy < b.Entropy
+	})
+	sort.SliceStable(report.Old, func(i, j int) bool {
+		return report.Old[i].Changed.Before(report.Old[j].Changed)
+	})
+	return report
+}
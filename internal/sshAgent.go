@@ -0,0 +1,259 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+var (
+	ErrNotSSHKey        = errors.New("field is not an ssh private key")
+	ErrInvalidLifetime  = errors.New("key lifetime must not be negative")
+	ErrAgentReadOnly    = errors.New("agent: keys are managed in the vault")
+	ErrAgentDenied      = errors.New("agent: signing request was denied")
+	ErrAgentKeyNotFound = errors.New("agent: key not found")
+)
+
+// SSHKey 条目作为 SSH 私钥提供给内置 ssh-agent 时的设置
+type SSHKey struct {
+	Field           string `json:"field"`                     // 保存私钥（OpenSSH 或 PEM 格式）的字段，加密的私钥用 password 字段解密
+	Confirm         bool   `json:"confirm,omitempty"`         // 每次签名前在主窗口请求确认
+	LifetimeMinutes int    `json:"lifetimeMinutes,omitempty"` // 解锁后可用多少分钟，0 表示一直可用到锁定
+}
+
+// SSHKeyRequest 等待用户确认的签名请求
+type SSHKeyRequest struct {
+	ID          string `json:"id"`
+	EntryID     string `json:"entryId"`
+	Name        string `json:"name"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// sshSigner 解析条目 field 字段中的私钥，需要密码时使用 password 字段
+func (e *Entry) sshSigner(field string) (ssh.Signer, error) {
+	f, ok := e.Field(field)
+	if !ok {
+		return nil, fmt.Errorf("%w: no field %q", ErrNotSSHKey, field)
+	}
+	signer, err := ssh.ParsePrivateKey([]byte(f.Value))
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		passphrase, _ := e.Field(FieldPassword)
+		signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(f.Value), []byte(passphrase.Value))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotSSHKey, err)
+	}
+	return signer, nil
+}
+
+// SetSSHKey 设置条目是否通过 ssh-agent 提供，key 为 nil 表示不提供。字段必须能解析为私钥
+func (v *Vault) SetSSHKey(id string, key *SSHKey) error {
+	entry, err := v.entry(id)
+	if err != nil {
+		return err
+	}
+	if key != nil {
+		if key.LifetimeMinutes < 0 {
+			return ErrInvalidLifetime
+		}
+		if _, err := entry.sshSigner(key.Field); err != nil {
+			return err
+		}
+	}
+	entry.SSHKey = key
+	entry.Modified = time.Now()
+	return nil
+}
+
+type agentKey struct {
+	entryID string
+	name    string
+	signer  ssh.Signer
+	confirm bool
+	expires time.Time // 零值表示一直可用到锁定
+}
+
+// SSHAgent 内置的 ssh-agent，只读地提供密码库中设置了 SSHKey 的条目。
+// 解锁和每次保存后由 Load 重新载入，锁定时由 Clear 清空，所以只在解锁期间可用
+type SSHAgent struct {
+	mu      sync.Mutex
+	keys    []agentKey
+	loaded  map[string]time.Time // 条目 ID 和公钥指纹 -> 第一次载入的时间，用于计算有效期
+	confirm func(req SSHKeyRequest) bool
+}
+
+// NewSSHAgent confirm 在签名设置了 Confirm 的密钥前调用，返回 false 时拒绝签名
+func NewSSHAgent(confirm func(req SSHKeyRequest) bool) *SSHAgent {
+	return &SSHAgent{loaded: make(map[string]time.Time), confirm: confirm}
+}
+
+// Load 从密码库重新载入密钥。已经载入过的密钥保留原来的载入时间，编辑其它条目不会延长有效期
+func (s *SSHAgent) Load(v *Vault) {
+	now := time.Now()
+	s.mu.Lock()
+	previous := s.loaded
+	s.mu.Unlock()
+	var keys []agentKey
+	loaded := make(map[string]time.Time)
+	var walk func(folder *Folder)
+	walk = func(folder *Folder) {
+		for _, child := range folder.Children {
+			switch n := child.(type) {
+			case *Entry:
+				if n.SSHKey == nil {
+					continue
+				}
+				signer, err := n.sshSigner(n.SSHKey.Field)
+				if err != nil {
+					slog.Warn("载入 SSH 密钥失败", "entry", n.Name, "err", err)
+					continue
+				}
+				id := n.ID + " " + ssh.FingerprintSHA256(signer.PublicKey())
+				loadedAt, ok := previous[id]
+				if !ok {
+					loadedAt = now
+				}
+				loaded[id] = loadedAt
+				key := agentKey{entryID: n.ID, name: n.Name, signer: signer, confirm: n.SSHKey.Confirm}
+				if n.SSHKey.LifetimeMinutes > 0 {
+					key.expires = loadedAt.Add(time.Duration(n.SSHKey.LifetimeMinutes) * time.Minute)
+				}
+				keys = append(keys, key)
+			case *Folder:
+				walk(n)
+			}
+		}
+	}
+	walk(v.Root)
+
+	s.mu.Lock()
+	s.keys, s.loaded = keys, loaded
+	s.mu.Unlock()
+}
+
+// Clear 移除所有密钥，下次 Load 时重新计算有效期
+func (s *SSHAgent) Clear() {
+	s.mu.Lock()
+	s.keys = nil
+	s.loaded = make(map[string]time.Time)
+	s.mu.Unlock()
+}
+
+// active 未过期的密钥，调用时需持有 s.mu
+func (s *SSHAgent) active(now time.Time) []agentKey {
+	var keys []agentKey
+	for _, k := range s.keys {
+		if k.expires.IsZero() || now.Before(k.expires) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func (s *SSHAgent) List() ([]*agent.Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := []*agent.Key{}
+	for _, k := range s.active(time.Now()) {
+		pub := k.signer.PublicKey()
+		result = append(result, &agent.Key{Format: pub.Type(), Blob: pub.Marshal(), Comment: k.name})
+	}
+	return result, nil
+}
+
+func (s *SSHAgent) Sign(key ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	return s.SignWithFlags(key, data, 0)
+}
+
+// SignWithFlags 找到对应的密钥签名，需要确认时在等待用户回应期间不持有锁
+func (s *SSHAgent) SignWithFlags(key ssh.PublicKey, data []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	s.mu.Lock()
+	var found *agentKey
+	wanted := key.Marshal()
+	for _, k := range s.active(time.Now()) {
+		if bytes.Equal(k.signer.PublicKey().Marshal(), wanted) {
+			found = &k
+			break
+		}
+	}
+	s.mu.Unlock()
+	if found == nil {
+		return nil, ErrAgentKeyNotFound
+	}
+
+	if found.confirm {
+		req := SSHKeyRequest{
+			ID:          newID(),
+			EntryID:     found.entryID,
+			Name:        found.name,
+			Fingerprint: ssh.FingerprintSHA256(found.signer.PublicKey()),
+		}
+		if s.confirm == nil || !s.confirm(req) {
+			return nil, ErrAgentDenied
+		}
+	}
+
+	var algorithm string
+	switch flags {
+	case 0:
+		return found.signer.Sign(rand.Reader, data)
+	case agent.SignatureFlagRsaSha256:
+		algorithm = ssh.KeyAlgoRSASHA256
+	case agent.SignatureFlagRsaSha512:
+		algorithm = ssh.KeyAlgoRSASHA512
+	default:
+		return nil, fmt.Errorf("agent: unsupported signature flags: %d", flags)
+	}
+	signer, ok := found.signer.(ssh.AlgorithmSigner)
+	if !ok {
+		return nil, fmt.Errorf("agent: %s key does not support %s", found.signer.PublicKey().Type(), algorithm)
+	}
+	return signer.SignWithAlgorithm(rand.Reader, data, algorithm)
+}
+
+func (s *SSHAgent) Signers() ([]ssh.Signer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var signers []ssh.Signer
+	for _, k := range s.active(time.Now()) {
+		signers = append(signers, k.signer)
+	}
+	return signers, nil
+}
+
+// 密钥只能在密码库中管理，客户端的 ssh-add 等修改操作一律拒绝
+
+func (s *SSHAgent) Add(key agent.AddedKey) error   { return ErrAgentReadOnly }
+func (s *SSHAgent) Remove(key ssh.PublicKey) error { return ErrAgentReadOnly }
+func (s *SSHAgent) RemoveAll() error               { return ErrAgentReadOnly }
+func (s *SSHAgent) Lock(passphrase []byte) error   { return ErrAgentReadOnly }
+func (s *SSHAgent) Unlock(passphrase []byte) error { return ErrAgentReadOnly }
+func (s *SSHAgent) Extension(string, []byte) ([]byte, error) {
+	return nil, agent.ErrExtensionUnsupported
+}
+
+// Serve 接受连接并按 ssh-agent 协议处理，listener 关闭后返回
+func (s *SSHAgent) Serve(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				slog.Error("ssh-agent 停止监听", "err", err)
+			}
+			return
+		}
+		go func() {
+			defer conn.Close()
+			agent.ServeAgent(s, conn)
+		}()
+	}
+}
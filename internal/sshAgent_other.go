@@ -0,0 +1,32 @@
+//go:build !windows
+
+package internal
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// DefaultSSHAgentPath 默认的 Unix socket，放在用户配置目录下
+func DefaultSSHAgentPath() string {
+	configDir, _ := os.UserConfigDir()
+	return filepath.Join(configDir, "quick-clip", "ssh-agent.sock")
+}
+
+// ListenSSHAgent 在 Unix socket 上监听，只允许当前用户连接。上次异常退出留下的 socket 文件先删除
+func ListenSSHAgent(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	os.Remove(path)
+	// 创建时就只有当前用户可读写，避免先监听再 chmod 之间的窗口里被其他用户连接
+	old := syscall.Umask(0177)
+	listener, err := net.Listen("unix", path)
+	syscall.Umask(old)
+	if err != nil {
+		return nil, err
+	}
+	return listener, nil
+}
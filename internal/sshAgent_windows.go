@@ -0,0 +1,26 @@
+//go:build windows
+
+package internal
+
+import (
+	"net"
+
+	"github.com/Microsoft/go-winio"
+	"golang.org/x/sys/windows"
+)
+
+// DefaultSSHAgentPath 默认的命名管道，不占用系统 OpenSSH 的 openssh-ssh-agent
+func DefaultSSHAgentPath() string {
+	return `\\.\pipe\quick-clip-ssh-agent`
+}
+
+// ListenSSHAgent 在命名管道上监听，只允许当前用户连接
+func ListenSSHAgent(path string) (net.Listener, error) {
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return nil, err
+	}
+	return winio.ListenPipe(path, &winio.PipeConfig{
+		SecurityDescriptor: "D:P(A;;GA;;;" + user.User.Sid.String() + ")",
+	})
+}
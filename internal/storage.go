@@ -0,0 +1,346 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// 读取失败的类型，前端根据 Kind 决定提示内容
+const (
+	ReadErrorNotFound = "not-found" // 文件不存在
+	ReadErrorDecrypt  = "decrypt"   // 解密失败：密码错误或文件被篡改
+	ReadErrorCorrupt  = "corrupt"   // 文件头损坏或解密后不是合法的 JSON
+)
+
+var ErrSafeMode = errors.New("vault is in safe mode, reset it or restore a backup first")
+
+// VaultReadError 读取密码库失败时返回的错误
+type VaultReadError struct {
+	Kind string
+	Err  error
+}
+
+func (e *VaultReadError) Error() string {
+	return fmt.Sprintf("read vault (%s): %v", e.Kind, e.Err)
+}
+
+func (e *VaultReadError) Unwrap() error {
+	return e.Err
+}
+
+// IsVaultCorrupt 判断 err 是否表示文件本身已经损坏，需要隔离
+func IsVaultCorrupt(err error) bool {
+	var readErr *VaultReadError
+	return errors.As(err, &readErr) && readErr.Kind == ReadErrorCorrupt
+}
+
+// UnlockVault 用 keys 打开 path 处的密码库并返回内容，成功后密钥会缓存在 keys 中。
+// 文件不存在时新建一个空库；没有文件头的旧文件会用 LegacyVaultKey
+// 解密后以新密钥重新加密保存（一次性迁移），旧版本格式和旧的 []any 明文会升级为当前格式
+func UnlockVault(path string, keys KeyProvider) (*Vault, error) {
+	if ReadSafeMode(path) != nil {
+		return nil, ErrSafeMode
+	}
+
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return createVault(path, keys, NewVault())
+	} else if err != nil {
+		return nil, err
+	}
+
+	key, header, plaintext, err := openVault(raw, keys)
+	if errors.Is(err, ErrNoVaultHeader) {
+		legacy, err := DecryptBytes(raw, LegacyVaultKey)
+		if err != nil {
+			return nil, &VaultReadError{Kind: ReadErrorCorrupt, Err: fmt.Errorf("migrate legacy vault: %w", err)}
+		}
+		vault, _, err := decodeContent(nil, legacy)
+		if err != nil {
+			return nil, err
+		}
+		return createVault(path, keys, vault)
+	} else if err != nil {
+		return nil, err
+	}
+
+	vault, legacyShape, err := decodeContent(header, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	if err := keys.CacheKey(key); err != nil {
+		return nil, err
+	}
+	if header.Version < vaultVersion || legacyShape {
+		if err := SaveContent(path, keys, vault); err != nil {
+			return nil, fmt.Errorf("upgrade vault: %w", err)
+		}
+	}
+	return vault, nil
+}
+
+// createVault 让 keys 生成新的文件头和密钥，并把 vault 写入 path
+func createVault(path string, keys KeyProvider, vault *Vault) (*Vault, error) {
+	if err := SaveContent(path, keys, vault); err != nil {
+		return nil, err
+	}
+	return vault, nil
+}
+
+// RekeyFile 用 newKeys 以新的 salt 重新加密 path 处的密码库（或备份），可同时添加或移除密钥文件。
+// 新内容先写入同目录下的临时文件，并通过 ReadContent 重新读取解密校验，
+// 校验通过后才替换原文件，在此之前原文件始终可以用旧密码打开
+func RekeyFile(path string, oldKeys KeyProvider, newKeys KeyProvider) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	key, header, plaintext, err := openVault(raw, oldKeys)
+	if err != nil {
+		return err
+	}
+	vault, _, err := decodeContent(header, plaintext)
+	if err != nil {
+		return err
+	}
+	// 缓存旧密钥，连续处理多个同 salt 的文件（主库和备份）时无需重复派生
+	if err := oldKeys.CacheKey(key); err != nil {
+		return err
+	}
+
+	tmpPath := path + ".rekey"
+	defer os.Remove(tmpPath)
+	os.Remove(tmpPath) // 上次中断留下的临时文件
+	if err := SaveContent(tmpPath, newKeys, vault); err != nil {
+		return err
+	}
+	if err := verifyContent(tmpPath, newKeys, vault); err != nil {
+		return err
+	}
+	return RenameDurable(tmpPath, path)
+}
+
+// CheckKey 确认 keys 能解密 path 处的密码库并缓存所用的密钥，之后对同一文件无需重新派生。
+// 密码错误时返回 ReadErrorDecrypt 类型的 *VaultReadError
+func CheckKey(path string, keys KeyProvider) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	key, header, plaintext, err := openVault(raw, keys)
+	if err != nil {
+		return err
+	}
+	if _, _, err := decodeContent(header, plaintext); err != nil {
+		return err
+	}
+	return keys.CacheKey(key)
+}
+
+// CurrentKey 取得与 path 处密码库当前文件头匹配的密钥，审计日志、撤销历史等附属文件用它加密
+func CurrentKey(path string, keys KeyProvider) (*VaultKey, error) {
+	header, err := ReadVaultHeader(path)
+	if err != nil {
+		return nil, err
+	}
+	return keys.ObtainKey(header)
+}
+
+// verifyContent 重新读取 path 并确认解密出的内容与 expected 一致
+func verifyContent(path string, keys KeyProvider, expected *Vault) error {
+	readBack, err := ReadContent(path, keys)
+	if err != nil {
+		return fmt.Errorf("verify re-encrypted vault: %w", err)
+	}
+	want, err := json.Marshal(expected)
+	if err != nil {
+		return err
+	}
+	got, err := json.Marshal(readBack)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(want, got) {
+		return errors.New("verify re-encrypted vault: content mismatch")
+	}
+	return nil
+}
+
+// openVault 在 OpenVault 基础上把错误归类为 VaultReadError
+func openVault(raw []byte, keys KeyProvider) (*VaultKey, *VaultHeader, []byte, error) {
+	key, header, plaintext, err := OpenVault(raw, keys)
+	switch {
+	case err == nil, errors.Is(err, ErrNoVaultHeader):
+		return key, header, plaintext, err
+	case errors.Is(err, ErrHeaderTruncated), errors.Is(err, ErrInvalidKDF):
+		return nil, nil, nil, &VaultReadError{Kind: ReadErrorCorrupt, Err: err}
+	case errors.Is(err, ErrWrongPassword):
+		return nil, nil, nil, &VaultReadError{Kind: ReadErrorDecrypt, Err: err}
+	}
+	return nil, nil, nil, err
+}
+
+// decodeContent 用 DecodeVault 解析解密后的 JSON 内容，失败时归类为文件损坏。
+// v1 文件没有认证，错误的密钥约有 1/256 的概率通过 CBC 填充检查，解析失败时按密码错误处理，不隔离文件。
+// header 为 nil 表示没有文件头的旧版本文件
+func decodeContent(header *VaultHeader, plaintext []byte) (*Vault, bool, error) {
+	vault, legacyShape, err := DecodeVault(plaintext)
+	if err != nil && header != nil && header.Version == vaultVersion1 {
+		return nil, false, &VaultReadError{Kind: ReadErrorDecrypt, Err: ErrWrongPassword}
+	} else if err != nil {
+		return nil, false, &VaultReadError{Kind: ReadErrorCorrupt, Err: err}
+	}
+	return vault, legacyShape, nil
+}
+
+// ReadContent 读取并解密密码库，旧格式的文件会被透明地升级为当前格式。
+// 失败时返回 *VaultReadError
+func ReadContent(path string, keys KeyProvider) (*Vault, error) {
+	vault, upgrade, err := readContent(path, keys)
+	if err != nil {
+		return nil, err
+	}
+	if upgrade {
+		if err := SaveContent(path, keys, vault); err != nil {
+			return nil, fmt.Errorf("upgrade vault: %w", err)
+		}
+	}
+	return vault, nil
+}
+
+// PeekContent 与 ReadContent 相同，但只读：不升级旧格式的文件，也不把密钥缓存到 keys 中，
+// 用于读取备份等不是当前密码库的文件
+func PeekContent(path string, keys KeyProvider) (*Vault, error) {
+	vault, _, err := readContent(path, keys)
+	return vault, err
+}
+
+// readContent 读取并解密 path，upgrade 表示文件是旧格式，需要重新保存
+func readContent(path string, keys KeyProvider) (vault *Vault, upgrade bool, err error) {
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, false, &VaultReadError{Kind: ReadErrorNotFound, Err: err}
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	_, header, plaintext, err := openVault(raw, keys)
+	if errors.Is(err, ErrNoVaultHeader) {
+		return nil, false, &VaultReadError{Kind: ReadErrorCorrupt, Err: err}
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	vault, legacyShape, err := decodeContent(header, plaintext)
+	if err != nil {
+		return nil, false, err
+	}
+	return vault, header.Version < vaultVersion || legacyShape, nil
+}
+
+// SafeModeInfo 密码库进入安全模式时记录的信息，保存在 path.safemode 中，重启后仍然有效
+type SafeModeInfo struct {
+	Kind           string    `json:"kind"`
+	Message        string    `json:"message"`
+	QuarantinePath string    `json:"quarantinePath"`
+	Time           time.Time `json:"time"`
+}
+
+func safeModePath(path string) string {
+	return path + ".safemode"
+}
+
+// QuarantineVault 把无法读取的密码库改名为带时间戳的隔离文件并进入安全模式。
+// 安全模式下不会新建或覆盖密码库，直到用户调用 ResetSafeMode
+func QuarantineVault(path string, cause error) (*SafeModeInfo, error) {
+	info := &SafeModeInfo{
+		Kind:    ReadErrorCorrupt,
+		Message: cause.Error(),
+		Time:    time.Now(),
+	}
+	var readErr *VaultReadError
+	if errors.As(cause, &readErr) {
+		info.Kind = readErr.Kind
+	}
+
+	quarantine := fmt.Sprintf("%s.corrupt-%s", path, info.Time.Format("20060102-150405"))
+	if err := os.Rename(path, quarantine); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	info.QuarantinePath = quarantine
+
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := WriteFileAtomic(safeModePath(path), data, 0644); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// ReadSafeMode 返回 path 的安全模式信息，不在安全模式时返回 nil
+func ReadSafeMode(path string) *SafeModeInfo {
+	data, err := os.ReadFile(safeModePath(path))
+	if err != nil {
+		return nil
+	}
+	info := &SafeModeInfo{}
+	if err := json.Unmarshal(data, info); err != nil {
+		// 标记文件本身损坏也视为仍处于安全模式
+		info.Kind = ReadErrorCorrupt
+		info.Message = err.Error()
+	}
+	return info
+}
+
+// ResetSafeMode 退出安全模式，隔离文件保留在原处，之后解锁会新建空的密码库
+func ResetSafeMode(path string) error {
+	err := os.Remove(safeModePath(path))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// 辅助函数：直接保存字节流，通过 WriteFileAtomic 原子写入。
+// 文件已存在时沿用它的文件头（salt、KDF 参数），否则由 keys 生成新的文件头。
+// 安全模式下拒绝写入
+func SaveContentBytes(path string, keys KeyProvider, byteData []byte) error {
+	if ReadSafeMode(path) != nil {
+		return ErrSafeMode
+	}
+
+	header, err := ReadVaultHeader(path)
+	if os.IsNotExist(err) || errors.Is(err, ErrNoVaultHeader) {
+		header = nil
+	} else if err != nil {
+		return err
+	}
+
+	key, err := keys.ObtainKey(header)
+	if err != nil {
+		return err
+	}
+	resource, err := SealVault(key, byteData)
+	if err != nil {
+		return err
+	}
+	if err := WriteFileAtomic(path, resource, 0644); err != nil {
+		return err
+	}
+	return keys.CacheKey(key)
+}
+
+// 你的 SaveContent 也可以简化调用这个辅助函数
+func SaveContent(path string, keys KeyProvider, vault *Vault) error {
+	byteData, err := json.Marshal(vault)
+	if err != nil {
+		return err
+	}
+	return SaveContentBytes(path, keys, byteData)
+}
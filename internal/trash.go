@@ -0,0 +1,134 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var ErrNotInTrash = errors.New("node is not in the trash")
+
+// TrashItem 回收站中的一个节点。ParentID 和 Path（原父文件夹从根开始的名字）用于恢复到原来的位置
+type TrashItem struct {
+	Node     Node      `json:"node"`
+	ParentID string    `json:"parentId"`
+	Path     []string  `json:"path"`
+	Index    int       `json:"index"`
+	Deleted  time.Time `json:"deleted"`
+}
+
+func (t *TrashItem) UnmarshalJSON(data []byte) error {
+	type trashItem TrashItem
+	var raw struct {
+		*trashItem
+		Node json.RawMessage `json:"node"`
+	}
+	raw.trashItem = (*trashItem)(t)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	node, err := decodeNode(raw.Node)
+	if err != nil {
+		return err
+	}
+	t.Node = node
+	return nil
+}
+
+// pathTo 返回 folder 从根开始的名字路径，根文件夹为空路径
+func (v *Vault) pathTo(folder *Folder) []string {
+	path := []string{}
+	for folder != v.Root {
+		_, parent, _ := v.Find(folder.ID)
+		if parent == nil {
+			break
+		}
+		path = append([]string{folder.Name}, path...)
+		folder = parent
+	}
+	return path
+}
+
+func (v *Vault) trashIndex(id string) int {
+	for i, item := range v.Trash {
+		if item.Node.NodeID() == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// RestoreNode 把回收站中的节点放回原来的位置。原父文件夹还在时放回原处，
+// 否则按 Path 逐级查找或重建文件夹；同名时自动改名
+func (v *Vault) RestoreNode(id string) error {
+	i := v.trashIndex(id)
+	if i < 0 {
+		return ErrNotInTrash
+	}
+	item := v.Trash[i]
+
+	parent, err := v.folder(item.ParentID)
+	if err != nil {
+		parent = v.Root
+		for _, name := range item.Path {
+			parent = v.ensureFolder(parent, name)
+		}
+	}
+
+	name := uniqueName(parent, item.Node.NodeName())
+	switch n := item.Node.(type) {
+	case *Entry:
+		n.Name = name
+	case *Folder:
+		n.Name = name
+	}
+	parent.Children = insertAt(parent.Children, item.Node, item.Index)
+	parent.Modified = time.Now()
+	v.Trash = append(v.Trash[:i:i], v.Trash[i+1:]...)
+	return nil
+}
+
+// ensureFolder 返回 parent 下名为 name 的文件夹，不存在时新建
+func (v *Vault) ensureFolder(parent *Folder, name string) *Folder {
+	for _, child := range parent.Children {
+		if folder, ok := child.(*Folder); ok && folder.Name == name {
+			return folder
+		}
+	}
+	folder := NewFolder(uniqueName(parent, name))
+	parent.Children = append(parent.Children, folder)
+	parent.Modified = folder.Created
+	return folder
+}
+
+// PurgeNode 从回收站中永久删除节点
+func (v *Vault) PurgeNode(id string) error {
+	i := v.trashIndex(id)
+	if i < 0 {
+		return ErrNotInTrash
+	}
+	v.Trash = append(v.Trash[:i:i], v.Trash[i+1:]...)
+	return nil
+}
+
+// EmptyTrash 永久删除回收站中的所有节点
+func (v *Vault) EmptyTrash() {
+	v.Trash = []*TrashItem{}
+}
+
+// PurgeTrash 永久删除在回收站中超过 days 天的节点，返回删除的个数。days <= 0 时不清理
+func (v *Vault) PurgeTrash(days int) int {
+	if days <= 0 {
+		return 0
+	}
+	cutoff := time.Now().AddDate(0, 0, -days)
+	kept := make([]*TrashItem, 0, len(v.Trash))
+	for _, item := range v.Trash {
+		if item.Deleted.After(cutoff) {
+			kept = append(kept, item)
+		}
+	}
+	purged := len(v.Trash) - len(kept)
+	v.Trash = kept
+	return purged
+}
@@ -3,17 +3,30 @@ package internal
 import (
 	"context"
 	_ "embed" // 必须引入
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"syscall"
 	"time"
+	"unsafe"
 
 	"github.com/energye/systray"
+	"github.com/tailscale/win"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 type AppInterface interface {
-	GetContent() []any
-	SaveContent(content []any)
+	ExportContent() (string, error)
+	ImportContent() error
+	TrashCount() int
+	Favourites() []*Entry
+	CopyEntry(id string) error
 }
 
+// maxTrayFavourites 托盘菜单中最多显示的收藏条目数
+const maxTrayFavourites = 20
+
 //go:embed asset/icon32.png
 var iconData []byte
 
@@ -22,13 +35,17 @@ type TrayManager struct {
 	isQuitting bool
 	action     *Action
 	app        AppInterface
+	vaults     *VaultService
+	notified   map[string]string // 条目 ID -> 最近一次弹出过期通知的日期，每个条目每天只通知一次
 }
 
-func NewTrayManager(action *Action, app AppInterface) *TrayManager {
+func NewTrayManager(action *Action, app AppInterface, vaults *VaultService) *TrayManager {
 	return &TrayManager{
 		isQuitting: false,
 		action:     action,
 		app:        app,
+		vaults:     vaults,
+		notified:   make(map[string]string),
 	}
 }
 
@@ -110,6 +127,24 @@ func (tm *TrayManager) onReady() {
 	mHotkey := systray.AddMenuItem("设置", "设置页面")
 	mOut := systray.AddMenuItem("导出", "导出Json")
 	mIn := systray.AddMenuItem("导入", "导入Json")
+	mTrash := systray.AddMenuItem("回收站", "查看回收站")
+	mFav := systray.AddMenuItem("收藏", "点击复制收藏的条目")
+	mExpiring := systray.AddMenuItem("过期提醒", "查看已过期和即将过期的条目")
+	mExpiring.Hide()
+
+	// 子菜单项预先创建，弹出菜单前按当前收藏刷新标题，多余的隐藏
+	favIDs := make([]string, maxTrayFavourites)
+	favItems := make([]*systray.MenuItem, maxTrayFavourites)
+	for i := range favItems {
+		item := mFav.AddSubMenuItem("", "复制到剪贴板")
+		item.Hide()
+		item.Click(func() {
+			if err := tm.app.CopyEntry(favIDs[i]); err != nil {
+				slog.Warn("复制收藏失败", "err", err)
+			}
+		})
+		favItems[i] = item
+	}
 	mQuit := systray.AddMenuItem("退出", "退出程序")
 
 	// 2. 【核心修改】使用回调函数，而不是 Channel
@@ -136,17 +171,42 @@ func (tm *TrayManager) onReady() {
 
 	// 导出Json
 	mOut.Click(func() {
-		tm.action.ExportJson(tm.app.GetContent(), tm.ctx)
+		if _, err := tm.app.ExportContent(); err != nil {
+			slog.Error("导出失败", "err", err)
+		}
 	})
 
 	// 导入Json
 	mIn.Click(func() {
-		content := tm.action.ImportJson(tm.ctx)
-		if content != nil {
-			tm.app.SaveContent(content)
+		if err := tm.app.ImportContent(); err != nil {
+			runtime.EventsEmit(tm.ctx, "save-failed", err.Error())
+		}
+	})
+
+	// 回收站
+	mTrash.Click(func() {
+		tm.action.ShowNoActivate()
+		runtime.EventsEmit(tm.ctx, "show-trash")
+	})
+
+	// 过期提醒：后台检查发现变化时更新托盘提示和菜单并弹出系统通知，锁定后隐藏
+	mExpiring.Click(func() {
+		tm.action.ShowNoActivate()
+		runtime.EventsEmit(tm.ctx, "show-expiring")
+	})
+	runtime.EventsOn(tm.ctx, "entries-expiring", func(data ...interface{}) {
+		var report []ExpiryItem
+		if len(data) > 0 {
+			report, _ = data[0].([]ExpiryItem)
+		}
+		tm.notifyExpiring(mExpiring, len(report))
+		tm.popupExpiring(report)
+	})
+	tm.vaults.Subscribe(func(e VaultEvent) {
+		// 在密码库的锁内调用，托盘菜单另起 goroutine 更新
+		if e.Kind == VaultLocked {
+			go tm.notifyExpiring(mExpiring, 0)
 		}
-		time.Sleep(time.Second)
-		runtime.EventsEmit(tm.ctx, "update-content")
 	})
 
 	// 如果需要设置托盘左键点击（显示窗口）
@@ -159,6 +219,25 @@ func (tm *TrayManager) onReady() {
 
 	// 托盘右键点击（显示菜单）
 	systray.SetOnRClick(func(menu systray.IMenu) {
+		// 每次弹出菜单前刷新回收站数量
+		mTrash.SetTitle(fmt.Sprintf("回收站 (%d)", tm.app.TrashCount()))
+		// 锁定时没有收藏可显示
+		favourites := tm.app.Favourites()
+		for i, item := range favItems {
+			if i < len(favourites) {
+				favIDs[i] = favourites[i].ID
+				item.SetTitle(favourites[i].Name)
+				item.Show()
+			} else {
+				favIDs[i] = ""
+				item.Hide()
+			}
+		}
+		if len(favourites) == 0 {
+			mFav.Disable()
+		} else {
+			mFav.Enable()
+		}
 		menu.ShowMenu()
 	})
 
@@ -168,6 +247,83 @@ func (tm *TrayManager) onReady() {
 	})
 }
 
+func (tm *TrayManager) notifyExpiring(item *systray.MenuItem, count int) {
+	if count == 0 {
+		item.Hide()
+		systray.SetTooltip("Quick-Clip")
+		return
+	}
+	item.SetTitle(fmt.Sprintf("过期提醒 (%d)", count))
+	item.Show()
+	systray.SetTooltip(fmt.Sprintf("Quick-Clip - %d 个条目已过期或即将过期", count))
+}
+
+// popupExpiring 为今天还没有通知过的条目弹出一条系统通知
+func (tm *TrayManager) popupExpiring(report []ExpiryItem) {
+	today := time.Now().Format(time.DateOnly)
+	var names []string
+	for _, item := range report {
+		if tm.notified[item.ID] != today {
+			tm.notified[item.ID] = today
+			names = append(names, item.Name)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	message := fmt.Sprintf("%d 个条目已过期或即将过期：%s", len(names), strings.Join(names, "、"))
+	if err := showTrayNotification("Quick-Clip", message); err != nil {
+		slog.Warn("弹出过期通知失败", "err", err)
+	}
+}
+
+// trayIconID systray 添加托盘图标时使用的 uID
+const trayIconID = 100
+
+// trayWindow 查找本进程中 systray 创建的隐藏窗口，托盘图标由它和 trayIconID 标识
+func trayWindow() win.HWND {
+	var target win.HWND
+	myPid := uint32(syscall.Getpid())
+	className := make([]uint16, 64)
+	cb := syscall.NewCallback(func(h win.HWND, l uintptr) uintptr {
+		var pid uint32
+		win.GetWindowThreadProcessId(h, &pid)
+		if pid == myPid {
+			n, _ := win.GetClassName(h, &className[0], len(className))
+			if syscall.UTF16ToString(className[:n]) == "SystrayClass" {
+				target = h
+				return 0
+			}
+		}
+		return 1
+	})
+	procEnumWindows.Call(cb, 0)
+	return target
+}
+
+// showTrayNotification 在托盘图标上弹出气泡通知，Windows 10 起显示为通知中心的消息
+func showTrayNotification(title string, message string) error {
+	hwnd := trayWindow()
+	if hwnd == 0 {
+		return errors.New("tray icon not found")
+	}
+	nid := win.NOTIFYICONDATA{HWnd: hwnd, UID: trayIconID, UFlags: win.NIF_INFO, DwInfoFlags: win.NIIF_WARNING}
+	nid.CbSize = uint32(unsafe.Sizeof(nid))
+	copyUTF16(nid.SzInfoTitle[:], title)
+	copyUTF16(nid.SzInfo[:], message)
+	if !win.Shell_NotifyIcon(win.NIM_MODIFY, &nid) {
+		return errors.New("Shell_NotifyIcon failed")
+	}
+	return nil
+}
+
+// copyUTF16 把 s 写入定长缓冲区，过长时截断并保留结尾的 0
+func copyUTF16(dst []uint16, s string) {
+	src, _ := syscall.UTF16FromString(s)
+	n := copy(dst[:len(dst)-1], src)
+	dst[n] = 0
+}
+
 func (tm *TrayManager) onExit() {
 	// 清理工作
 }
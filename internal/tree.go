@@ -0,0 +1,333 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNodeNotFound  = errors.New("node not found")
+	ErrNotAFolder    = errors.New("node is not a folder")
+	ErrNotAnEntry    = errors.New("node is not an entry")
+	ErrFieldNotFound = errors.New("entry has no such field")
+	ErrEmptyName     = errors.New("name must not be empty")
+	ErrDuplicateName = errors.New("a sibling with this name already exists")
+	ErrMoveIntoSelf  = errors.New("cannot move a folder into itself")
+	ErrRootImmutable = errors.New("the root folder cannot be changed")
+)
+
+// Find 按 ID 查找节点，返回节点、其父文件夹和它在父文件夹中的下标。根文件夹的父节点为 nil
+func (v *Vault) Find(id string) (Node, *Folder, int) {
+	if v.Root.ID == id {
+		return v.Root, nil, -1
+	}
+	return findIn(v.Root, id)
+}
+
+func findIn(folder *Folder, id string) (Node, *Folder, int) {
+	for i, child := range folder.Children {
+		if child.NodeID() == id {
+			return child, folder, i
+		}
+		if sub, ok := child.(*Folder); ok {
+			if node, parent, index := findIn(sub, id); node != nil {
+				return node, parent, index
+			}
+		}
+	}
+	return nil, nil, -1
+}
+
+// folder 按 ID 查找文件夹，空 ID 表示根文件夹
+func (v *Vault) folder(id string) (*Folder, error) {
+	if id == "" {
+		return v.Root, nil
+	}
+	node, _, _ := v.Find(id)
+	if node == nil {
+		return nil, ErrNodeNotFound
+	}
+	folder, ok := node.(*Folder)
+	if !ok {
+		return nil, ErrNotAFolder
+	}
+	return folder, nil
+}
+
+// entry 按 ID 查找条目
+func (v *Vault) entry(id string) (*Entry, error) {
+	node, _, _ := v.Find(id)
+	if node == nil {
+		return nil, ErrNodeNotFound
+	}
+	entry, ok := node.(*Entry)
+	if !ok {
+		return nil, ErrNotAnEntry
+	}
+	return entry, nil
+}
+
+// checkName 名字不能为空，且不能与 parent 中除 exceptID 以外的子节点重名
+func checkName(parent *Folder, name string, exceptID string) error {
+	if strings.TrimSpace(name) == "" {
+		return ErrEmptyName
+	}
+	for _, child := range parent.Children {
+		if child.NodeName() == name && child.NodeID() != exceptID {
+			return fmt.Errorf("%w: %q", ErrDuplicateName, name)
+		}
+	}
+	return nil
+}
+
+// insertAt 把 node 插入到 children 的 index 处，index 越界（包括 -1）时追加到末尾
+func insertAt(children []Node, node Node, index int) []Node {
+	if index < 0 || index >= len(children) {
+		return append(children, node)
+	}
+	children = append(children, nil)
+	copy(children[index+1:], children[index:])
+	children[index] = node
+	return children
+}
+
+func removeAt(children []Node, index int) []Node {
+	return append(children[:index:index], children[index+1:]...)
+}
+
+// CreateEntry 在 parentID 下新建条目，index 为 -1 时追加到末尾
+func (v *Vault) CreateEntry(parentID string, name string, fields []Field, index int) (*Entry, error) {
+	parent, err := v.folder(parentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkName(parent, name, ""); err != nil {
+		return nil, err
+	}
+	entry := NewFieldsEntry(name, fields)
+	parent.Children = insertAt(parent.Children, entry, index)
+	parent.Modified = entry.Created
+	return entry, nil
+}
+
+// CreateFolder 在 parentID 下新建空文件夹，index 为 -1 时追加到末尾
+func (v *Vault) CreateFolder(parentID string, name string, index int) (*Folder, error) {
+	parent, err := v.folder(parentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkName(parent, name, ""); err != nil {
+		return nil, err
+	}
+	folder := NewFolder(name)
+	parent.Children = insertAt(parent.Children, folder, index)
+	parent.Modified = folder.Created
+	return folder, nil
+}
+
+// UpdateEntry 替换条目的全部字段，被修改或删除的旧值连同 reason 记入条目历史，最多保留 keep 个。
+// 设置了轮换周期的条目修改了敏感字段时，过期时间从现在起重新计算
+func (v *Vault) UpdateEntry(id string, fields []Field, reason string, keep int) error {
+	entry, err := v.entry(id)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if entry.RotationDays > 0 && rotated(entry.Fields, fields) {
+		next := now.AddDate(0, 0, entry.RotationDays)
+		entry.ExpiresAt = &next
+	}
+	entry.recordHistory(fields, reason, keep, now)
+	entry.Fields = fields
+	entry.Modified = now
+	return nil
+}
+
+// RenameNode 重命名条目或文件夹
+func (v *Vault) RenameNode(id string, name string) error {
+	node, parent, _ := v.Find(id)
+	if node == nil {
+		return ErrNodeNotFound
+	}
+	if parent == nil {
+		return ErrRootImmutable
+	}
+	if err := checkName(parent, name, id); err != nil {
+		return err
+	}
+	now := time.Now()
+	switch n := node.(type) {
+	case *Entry:
+		n.Name, n.Modified = name, now
+	case *Folder:
+		n.Name, n.Modified = name, now
+	}
+	return nil
+}
+
+// MoveNode 把节点移动到 newParentID 下的 index 处。index 按移动前的列表计算，
+// 即“插到当前位于 index 的节点之前”，-1 表示追加到末尾。文件夹不能移动到自身或其子孙中
+func (v *Vault) MoveNode(id string, newParentID string, index int) error {
+	node, parent, oldIndex := v.Find(id)
+	if node == nil {
+		return ErrNodeNotFound
+	}
+	if parent == nil {
+		return ErrRootImmutable
+	}
+	dest, err := v.folder(newParentID)
+	if err != nil {
+		return err
+	}
+	if folder, ok := node.(*Folder); ok {
+		if found, _, _ := findIn(folder, dest.ID); found != nil || dest == folder {
+			return ErrMoveIntoSelf
+		}
+	}
+	if err := checkName(dest, node.NodeName(), id); err != nil {
+		return err
+	}
+
+	parent.Children = removeAt(parent.Children, oldIndex)
+	if dest == parent && index > oldIndex {
+		index--
+	}
+	dest.Children = insertAt(dest.Children, node, index)
+	now := time.Now()
+	parent.Modified, dest.Modified = now, now
+	return nil
+}
+
+// DeleteNode 把节点（文件夹连同其内容）移入回收站，记录原来的位置以便恢复
+func (v *Vault) DeleteNode(id string) error {
+	node, parent, index := v.Find(id)
+	if node == nil {
+		return ErrNodeNotFound
+	}
+	if parent == nil {
+		return ErrRootImmutable
+	}
+	now := time.Now()
+	parent.Children = removeAt(parent.Children, index)
+	parent.Modified = now
+	v.Trash = append(v.Trash, &TrashItem{
+		Node:     node,
+		ParentID: parent.ID,
+		Path:     v.pathTo(parent),
+		Index:    index,
+		Deleted:  now,
+	})
+	return nil
+}
+
+// DuplicateNode 深拷贝节点并放在原节点之后，所有节点获得新的 ID，名字加上不重复的后缀
+func (v *Vault) DuplicateNode(id string) (Node, error) {
+	node, parent, index := v.Find(id)
+	if node == nil {
+		return nil, ErrNodeNotFound
+	}
+	if parent == nil {
+		return nil, ErrRootImmutable
+	}
+	dup, err := cloneNode(node)
+	if err != nil {
+		return nil, err
+	}
+	name := uniqueName(parent, node.NodeName()+" (copy)")
+	switch n := dup.(type) {
+	case *Entry:
+		n.Name = name
+	case *Folder:
+		n.Name = name
+	}
+	parent.Children = insertAt(parent.Children, dup, index+1)
+	parent.Modified = time.Now()
+	return dup, nil
+}
+
+// uniqueName 在 parent 中为 base 生成不重名的名字：base、base 2、base 3……
+func uniqueName(parent *Folder, base string) string {
+	name := base
+	for i := 2; checkName(parent, name, "") != nil; i++ {
+		name = fmt.Sprintf("%s %d", base, i)
+	}
+	return name
+}
+
+// cloneNode 深拷贝节点，拷贝及其所有子孙都使用新的 ID 和当前时间
+func cloneNode(node Node) (Node, error) {
+	data, err := json.Marshal(node)
+	if err != nil {
+		return nil, err
+	}
+	dup, err := decodeNode(data)
+	if err != nil {
+		return nil, err
+	}
+	renew(dup, time.Now())
+	return dup, nil
+}
+
+func renew(node Node, now time.Time) {
+	switch n := node.(type) {
+	case *Entry:
+		n.ID, n.Created, n.Modified = newID(), now, now
+	case *Folder:
+		n.ID, n.Created, n.Modified = newID(), now, now
+		for _, child := range n.Children {
+			renew(child, now)
+		}
+	}
+}
+
+// Clone 深拷贝整个密码库（保留 ID），用于先在副本上修改、保存成功后再替换。
+// 附件内容不可变，只复制 Blobs 这张表
+func (v *Vault) Clone() (*Vault, error) {
+	data, err := json.Marshal(v.WithoutBlobs())
+	if err != nil {
+		return nil, err
+	}
+	clone := &Vault{}
+	if err := json.Unmarshal(data, clone); err != nil {
+		return nil, err
+	}
+	clone.Blobs = maps.Clone(v.Blobs)
+	return clone, nil
+}
+
+// Redacted 返回发给前端显示的副本：不含附件内容，敏感字段（包括历史中的旧值）、验证码种子和 SSH 私钥字段的值被清空。
+// 明文只能通过记入审计日志的接口取得
+func (v *Vault) Redacted() (*Vault, error) {
+	clone, err := v.WithoutBlobs().Clone()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range clone.entriesByID() {
+		e.redact()
+	}
+	return clone, nil
+}
+
+// redact 清空条目中不应直接显示的值，只能在副本上调用
+func (e *Entry) redact() {
+	hidden := func(f Field) bool {
+		return f.Sensitive || (e.SSHKey != nil && f.Name == e.SSHKey.Field)
+	}
+	for i, f := range e.Fields {
+		if hidden(f) {
+			e.Fields[i].Value = ""
+		}
+	}
+	for i, h := range e.History {
+		if hidden(h.Field) {
+			e.History[i].Value = ""
+		}
+	}
+	if e.OTP != nil {
+		e.OTP.Secret = ""
+	}
+}
@@ -0,0 +1,528 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// vaultModelVersion 密码库明文 JSON 的结构版本。旧版本的明文是 []any，没有这个字段。
+// 1: 条目只有一个 value；2: 条目由多个字段组成
+const vaultModelVersion = 2
+
+const (
+	nodeTypeFolder = "folder"
+	nodeTypeEntry  = "entry"
+)
+
+var ErrLegacyShape = errors.New("unsupported legacy vault item")
+
+// Node 文件夹中的子节点，*Folder 或 *Entry
+type Node interface {
+	NodeID() string
+	NodeName() string
+	NodeLabels() *Labels
+}
+
+// 标准字段名，其它名字都是自定义字段。旧版本只有一个值的条目对应 FieldValue
+const (
+	FieldValue    = "value"
+	FieldUsername = "username"
+	FieldPassword = "password"
+	FieldURL      = "url"
+	FieldNotes    = "notes"
+)
+
+// Field 条目中的一个字段，Sensitive 的字段在界面上默认隐藏
+type Field struct {
+	Name      string `json:"name"`
+	Value     string `json:"value"`
+	Sensitive bool   `json:"sensitive"`
+}
+
+// Entry 一个条目，由有序的字段组成。对应旧格式中值为字符串（只有 value 字段）
+// 或 {"fields": [...]} 的单键对象
+type Entry struct {
+	ID      string         `json:"id"`
+	Name    string         `json:"name"`
+	Fields  []Field        `json:"fields"`
+	History []FieldVersion `json:"history,omitempty"` // 字段的旧值，从旧到新
+	// 附件信息，内容在 Vault.Blobs 中
+	Attachments []Attachment `json:"attachments,omitempty"`
+	// 过期时间和轮换周期（天），轮换周期不为 0 时修改敏感字段会顺延过期时间
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty"`
+	RotationDays int        `json:"rotationDays,omitempty"`
+	OTP          *OTP       `json:"otp,omitempty"`    // 一次性验证码，一键复制和粘贴时使用当前验证码
+	SSHKey       *SSHKey    `json:"sshKey,omitempty"` // 通过内置 ssh-agent 提供的私钥
+	Labels
+	Created  time.Time `json:"created"`
+	Modified time.Time `json:"modified"`
+}
+
+// Folder 一个文件夹，对应旧格式中值为数组的单键对象，Children 保持用户排列的顺序
+type Folder struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Labels
+	Generator *GeneratorPolicy `json:"generator,omitempty"` // 在此文件夹下新建条目时的默认生成规则，nil 表示继承上级
+	Created   time.Time        `json:"created"`
+	Modified  time.Time        `json:"modified"`
+	Children  []Node           `json:"children"`
+}
+
+// Vault 解密后的密码库，所有内容挂在没有名字的根文件夹下，删除的节点放在 Trash 中，
+// 附件内容按 ID 放在 Blobs 中
+type Vault struct {
+	Version int               `json:"version"`
+	Root    *Folder           `json:"root"`
+	Trash   []*TrashItem      `json:"trash"`
+	Blobs   map[string][]byte `json:"blobs,omitempty"`
+	// 上次保存时审计日志至少已有的记录数，日志和 .head 文件被一起删除时据此发现
+	AuditSeq int `json:"auditSeq,omitempty"`
+}
+
+func (e *Entry) NodeID() string    { return e.ID }
+func (e *Entry) NodeName() string  { return e.Name }
+func (f *Folder) NodeID() string   { return f.ID }
+func (f *Folder) NodeName() string { return f.Name }
+
+// newID 生成随机的 UUID v4
+func newID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// NewEntry 创建一个只有 value 字段的条目
+func NewEntry(name string, value string) *Entry {
+	return NewFieldsEntry(name, []Field{{Name: FieldValue, Value: value}})
+}
+
+func NewFieldsEntry(name string, fields []Field) *Entry {
+	now := time.Now()
+	return &Entry{ID: newID(), Name: name, Fields: fields, Created: now, Modified: now}
+}
+
+// Field 按名字查找字段
+func (e *Entry) Field(name string) (Field, bool) {
+	for _, f := range e.Fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// isSingleValue 条目是否只有一个非敏感的 value 字段，可以用旧格式的字符串表示
+func (e *Entry) isSingleValue() bool {
+	return len(e.Fields) == 1 && e.Fields[0] == Field{Name: FieldValue, Value: e.Fields[0].Value}
+}
+
+func NewFolder(name string) *Folder {
+	now := time.Now()
+	return &Folder{ID: newID(), Name: name, Created: now, Modified: now, Children: []Node{}}
+}
+
+func NewVault() *Vault {
+	return &Vault{Version: vaultModelVersion, Root: NewFolder(""), Trash: []*TrashItem{}}
+}
+
+// MarshalJSON 子节点序列化时带上 type 字段，反序列化时据此区分文件夹和条目
+func (e *Entry) MarshalJSON() ([]byte, error) {
+	type entry Entry
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		*entry
+	}{nodeTypeEntry, (*entry)(e)})
+}
+
+// UnmarshalJSON 兼容结构版本 1 中只有 value 的条目
+func (e *Entry) UnmarshalJSON(data []byte) error {
+	type entry Entry
+	var raw struct {
+		*entry
+		Value *string `json:"value"`
+	}
+	raw.entry = (*entry)(e)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if e.Fields == nil && raw.Value != nil {
+		e.Fields = []Field{{Name: FieldValue, Value: *raw.Value}}
+	}
+	return nil
+}
+
+func (f *Folder) MarshalJSON() ([]byte, error) {
+	type folder Folder
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		*folder
+	}{nodeTypeFolder, (*folder)(f)})
+}
+
+func (f *Folder) UnmarshalJSON(data []byte) error {
+	type folder Folder
+	var raw struct {
+		*folder
+		Children []json.RawMessage `json:"children"`
+	}
+	raw.folder = (*folder)(f)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	f.Children = make([]Node, 0, len(raw.Children))
+	for _, child := range raw.Children {
+		node, err := decodeNode(child)
+		if err != nil {
+			return err
+		}
+		f.Children = append(f.Children, node)
+	}
+	return nil
+}
+
+// decodeNode 根据 type 字段把 JSON 解析为 *Folder 或 *Entry
+func decodeNode(data []byte) (Node, error) {
+	var kind struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &kind); err != nil {
+		return nil, err
+	}
+	var node Node
+	switch kind.Type {
+	case nodeTypeFolder:
+		node = &Folder{}
+	case nodeTypeEntry:
+		node = &Entry{}
+	default:
+		return nil, fmt.Errorf("unknown vault node type %q", kind.Type)
+	}
+	if err := json.Unmarshal(data, node); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// DecodeVault 解析解密后的明文。旧版本的 []any 会被转换，legacy 为 true 表示需要以新结构重新保存
+func DecodeVault(plaintext []byte) (vault *Vault, legacy bool, err error) {
+	trimmed := bytes.TrimSpace(plaintext)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var json = jsoniter.ConfigCompatibleWithStandardLibrary
+		var content []any
+		if err := json.Unmarshal(trimmed, &content); err != nil {
+			return nil, true, err
+		}
+		vault, err := VaultFromLegacy(content)
+		return vault, true, err
+	}
+
+	vault = &Vault{}
+	if err := json.Unmarshal(trimmed, vault); err != nil {
+		return nil, false, err
+	}
+	if vault.Root == nil {
+		return nil, false, errors.New("vault has no root folder")
+	}
+	if vault.Trash == nil {
+		vault.Trash = []*TrashItem{}
+	}
+	if vault.Version < vaultModelVersion {
+		vault.Version = vaultModelVersion
+		return vault, true, nil
+	}
+	return vault, false, nil
+}
+
+// legacyItem 把旧格式的一个单键对象拆成 (名字, 值) 列表，多个键时按名字排序保证结果稳定
+func legacyItem(item any) ([]string, map[string]any, error) {
+	m, ok := item.(map[string]any)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %T", ErrLegacyShape, item)
+	}
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, m, nil
+}
+
+// VaultFromLegacy 把旧格式 []any 转换为 Vault，为每个节点生成新的 ID
+func VaultFromLegacy(content []any) (*Vault, error) {
+	vault := NewVault()
+	children, err := vault.mergeLegacy(nil, content, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	vault.Root.Children = children
+	return vault, nil
+}
+
+// ToLegacy 转换回旧格式 []any，供仍按下标路径操作的前端和导入导出使用
+func (v *Vault) ToLegacy() []any {
+	return v.folderToLegacy(v.Root)
+}
+
+func (v *Vault) folderToLegacy(f *Folder) []any {
+	content := make([]any, 0, len(f.Children))
+	for _, child := range f.Children {
+		switch n := child.(type) {
+		case *Entry:
+			content = append(content, map[string]any{n.Name: v.entryToLegacy(n)})
+		case *Folder:
+			content = append(content, map[string]any{n.Name: v.folderToLegacy(n)})
+		}
+	}
+	return content
+}
+
+// entryToLegacy 只有 value 字段的条目保持旧格式的字符串，其它条目表示为 {"fields": [...]}，
+// 有附件时再加上 "attachments": [{"name", "data"}]，data 为 base64；有验证码时加上 "otp": "otpauth://..."
+func (v *Vault) entryToLegacy(e *Entry) any {
+	if e.isSingleValue() && len(e.Attachments) == 0 && e.OTP == nil {
+		return e.Fields[0].Value
+	}
+	fields := make([]any, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		fields = append(fields, map[string]any{"name": f.Name, "value": f.Value, "sensitive": f.Sensitive})
+	}
+	result := map[string]any{"fields": fields}
+	if len(e.Attachments) > 0 {
+		attachments := make([]any, 0, len(e.Attachments))
+		for _, a := range e.Attachments {
+			attachments = append(attachments, map[string]any{"name": a.Name, "data": base64.StdEncoding.EncodeToString(v.Blobs[a.ID])})
+		}
+		result["attachments"] = attachments
+	}
+	if e.OTP != nil {
+		result["otp"] = e.OTP.URI()
+	}
+	return result
+}
+
+// fieldsFromLegacy 解析旧格式中条目的值：字符串或 {"fields": [...]}，附件和验证码分别由
+// attachmentsFromLegacy 和 otpFromLegacy 解析
+func fieldsFromLegacy(name string, value any) ([]Field, error) {
+	if s, ok := value.(string); ok {
+		return []Field{{Name: FieldValue, Value: s}}, nil
+	}
+	m, ok := value.(map[string]any)
+	raw, hasFields := m["fields"].([]any)
+	extra := len(m) - 1
+	if _, hasAttachments := m["attachments"]; hasAttachments {
+		extra--
+	}
+	if _, hasOTP := m["otp"]; hasOTP {
+		extra--
+	}
+	if !ok || !hasFields || extra != 0 {
+		return nil, fmt.Errorf("%w: %q is %T", ErrLegacyShape, name, value)
+	}
+	fields := make([]Field, 0, len(raw))
+	for _, item := range raw {
+		fm, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%w: field of %q is %T", ErrLegacyShape, name, item)
+		}
+		var f Field
+		var okName, okValue, okSensitive bool
+		f.Name, okName = fm["name"].(string)
+		f.Value, okValue = fm["value"].(string)
+		f.Sensitive, okSensitive = fm["sensitive"].(bool)
+		if !okName || !okValue || (!okSensitive && fm["sensitive"] != nil) {
+			return nil, fmt.Errorf("%w: malformed field of %q", ErrLegacyShape, name)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// attachmentsFromLegacy 解析旧格式条目中的附件。old 中名字和内容都相同的附件沿用原来的 ID，
+// 其它附件生成新的 ID 并把内容放入 Blobs
+func (v *Vault) attachmentsFromLegacy(name string, value any, old *Entry, now time.Time) ([]Attachment, error) {
+	m, _ := value.(map[string]any)
+	raw, ok := m["attachments"].([]any)
+	if !ok {
+		if m["attachments"] != nil {
+			return nil, fmt.Errorf("%w: attachments of %q is %T", ErrLegacyShape, name, m["attachments"])
+		}
+		return nil, nil
+	}
+	attachments := make([]Attachment, 0, len(raw))
+	for _, item := range raw {
+		am, _ := item.(map[string]any)
+		fileName, okName := am["name"].(string)
+		encoded, okData := am["data"].(string)
+		if !okName || !okData {
+			return nil, fmt.Errorf("%w: malformed attachment of %q", ErrLegacyShape, name)
+		}
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("%w: attachment %q of %q: %v", ErrLegacyShape, fileName, name, err)
+		}
+		attachment, reused := Attachment{}, false
+		if old != nil {
+			for _, a := range old.Attachments {
+				if a.Name == fileName && bytes.Equal(v.Blobs[a.ID], data) {
+					attachment, reused = a, true
+					break
+				}
+			}
+		}
+		if !reused {
+			attachment = Attachment{ID: newID(), Name: fileName, Size: int64(len(data)), Added: now}
+			if v.Blobs == nil {
+				v.Blobs = make(map[string][]byte)
+			}
+			v.Blobs[attachment.ID] = data
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments, nil
+}
+
+// otpFromLegacy 解析旧格式条目中的 "otp" URI，没有时返回 nil
+func otpFromLegacy(name string, value any) (*OTP, error) {
+	m, _ := value.(map[string]any)
+	if m["otp"] == nil {
+		return nil, nil
+	}
+	uri, ok := m["otp"].(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: otp of %q is %T", ErrLegacyShape, name, m["otp"])
+	}
+	otp, err := ParseOTPURI(uri)
+	if err != nil {
+		return nil, fmt.Errorf("%w: otp of %q: %v", ErrLegacyShape, name, err)
+	}
+	return otp, nil
+}
+
+// ApplyLegacy 用前端提交的旧格式内容更新 Vault。按名字和类型依次匹配原有节点，
+// 匹配上的节点保留 ID 和创建时间，内容有变化时更新修改时间
+func (v *Vault) ApplyLegacy(content []any) error {
+	children, err := v.mergeLegacy(v.Root, content, time.Now())
+	if err != nil {
+		return err
+	}
+	if !sameChildren(v.Root.Children, children) {
+		v.Root.Modified = time.Now()
+	}
+	v.Root.Children = children
+	v.PruneBlobs()
+	return nil
+}
+
+// takeNode 从 pool 中取出第一个名字和类型都相同的节点
+func takeNode(pool []Node, name string, isFolder bool) (Node, []Node) {
+	for i, n := range pool {
+		if n.NodeName() != name {
+			continue
+		}
+		if _, ok := n.(*Folder); ok == isFolder {
+			return n, append(pool[:i:i], pool[i+1:]...)
+		}
+	}
+	return nil, pool
+}
+
+func (v *Vault) mergeLegacy(old *Folder, content []any, now time.Time) ([]Node, error) {
+	var pool []Node
+	if old != nil {
+		pool = old.Children
+	}
+	children := make([]Node, 0, len(content))
+	for _, item := range content {
+		names, m, err := legacyItem(item)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			switch value := m[name].(type) {
+			case []any:
+				var match Node
+				match, pool = takeNode(pool, name, true)
+				oldFolder, _ := match.(*Folder)
+				var folder Folder
+				if oldFolder == nil {
+					folder = *NewFolder(name)
+				} else {
+					folder = *oldFolder
+				}
+				sub, err := v.mergeLegacy(oldFolder, value, now)
+				if err != nil {
+					return nil, err
+				}
+				if oldFolder != nil && !sameChildren(folder.Children, sub) {
+					folder.Modified = now
+				}
+				folder.Children = sub
+				children = append(children, &folder)
+			default:
+				fields, err := fieldsFromLegacy(name, value)
+				if err != nil {
+					return nil, err
+				}
+				var match Node
+				match, pool = takeNode(pool, name, false)
+				oldEntry, _ := match.(*Entry)
+				attachments, err := v.attachmentsFromLegacy(name, value, oldEntry, now)
+				if err != nil {
+					return nil, err
+				}
+				otp, err := otpFromLegacy(name, value)
+				if err != nil {
+					return nil, err
+				}
+				if oldEntry == nil {
+					entry := NewFieldsEntry(name, fields)
+					entry.Attachments = attachments
+					entry.OTP = otp
+					children = append(children, entry)
+					continue
+				}
+				entry := *oldEntry
+				sameOTP := entry.OTP == otp || (entry.OTP != nil && otp != nil && *entry.OTP == *otp)
+				if !slices.Equal(entry.Fields, fields) || !slices.Equal(entry.Attachments, attachments) || !sameOTP {
+					entry.Fields = fields
+					entry.Attachments = attachments
+					entry.OTP = otp
+					entry.Modified = now
+				}
+				children = append(children, &entry)
+			}
+		}
+	}
+	return children, nil
+}
+
+// sameChildren 子节点的 ID 顺序和条目内容是否都没有变化
+func sameChildren(a []Node, b []Node) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].NodeID() != b[i].NodeID() {
+			return false
+		}
+		if ea, ok := a[i].(*Entry); ok && !ea.Modified.Equal(b[i].(*Entry).Modified) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,165 @@
+package internal
+
+import (
+	"sync"
+	"time"
+)
+
+// VaultEventKind 密码库变化的类型
+type VaultEventKind string
+
+const (
+	VaultUnlocked VaultEventKind = "unlocked"
+	VaultChanged  VaultEventKind = "changed" // 修改、导入、撤销/重做或恢复备份后保存了新的版本
+	VaultLocked   VaultEventKind = "locked"
+)
+
+// VaultEvent 一次变化。Before 和 After 是只读快照，解锁时 Before 为 nil，锁定时 After 为 nil
+type VaultEvent struct {
+	Kind   VaultEventKind
+	Op     string // 修改的操作名，如 "rename"、"undo rename"
+	Before *Vault
+	After  *Vault
+	Keys   KeyProvider // 当前的密钥，锁定时为 nil，供需要加密写入的订阅者使用
+}
+
+// VaultService 持有解锁后的密码库和密钥，所有读取和修改都在它的锁内进行。
+// 密码库只会被整个替换、不会原地修改，所以交出去的 *Vault 就是不会再变化的快照
+type VaultService struct {
+	mu           sync.Mutex // 保护 vault、keys 和 lastActivity，修改时在保存的整个过程中持有
+	vault        *Vault     // 锁定时为 nil
+	keys         KeyProvider
+	lastActivity time.Time
+
+	subMu       sync.Mutex
+	subscribers map[int]func(VaultEvent)
+	nextID      int
+}
+
+func NewVaultService() *VaultService {
+	return &VaultService{subscribers: make(map[int]func(VaultEvent))}
+}
+
+// Subscribe 注册变化通知，返回取消订阅的函数。
+// fn 在持有锁时按发生顺序同步调用，不能再调用 VaultService 的方法，耗时的工作应另起 goroutine
+func (s *VaultService) Subscribe(fn func(VaultEvent)) func() {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	id := s.nextID
+	s.nextID++
+	s.subscribers[id] = fn
+	return func() {
+		s.subMu.Lock()
+		delete(s.subscribers, id)
+		s.subMu.Unlock()
+	}
+}
+
+// publish 通知所有订阅者，调用时需持有 s.mu
+func (s *VaultService) publish(event VaultEvent) {
+	s.subMu.Lock()
+	subscribers := make([]func(VaultEvent), 0, len(s.subscribers))
+	for id := 0; id < s.nextID; id++ {
+		if fn, ok := s.subscribers[id]; ok {
+			subscribers = append(subscribers, fn)
+		}
+	}
+	s.subMu.Unlock()
+	for _, fn := range subscribers {
+		fn(event)
+	}
+}
+
+// Open 解锁后换入密码库和密钥并通知订阅者
+func (s *VaultService) Open(vault *Vault, keys KeyProvider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.keys != nil && s.keys != keys {
+		s.keys.ForgetKey()
+	}
+	s.vault, s.keys = vault, keys
+	s.lastActivity = time.Now()
+	s.publish(VaultEvent{Kind: VaultUnlocked, After: vault, Keys: keys})
+}
+
+// Close 丢弃密钥和明文内容并通知订阅者，已经锁定时返回 false
+func (s *VaultService) Close() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.keys == nil {
+		return false
+	}
+	before := s.vault
+	s.keys.ForgetKey()
+	s.vault, s.keys = nil, nil
+	s.publish(VaultEvent{Kind: VaultLocked, Before: before})
+	return true
+}
+
+// Unlocked 是否已经解锁
+func (s *VaultService) Unlocked() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.keys != nil
+}
+
+// Snapshot 当前密码库的只读快照，锁定时返回 ErrVaultLocked
+func (s *VaultService) Snapshot() (*Vault, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.keys == nil {
+		return nil, ErrVaultLocked
+	}
+	return s.vault, nil
+}
+
+// Touch 记录一次用户操作，用于空闲自动锁定
+func (s *VaultService) Touch() {
+	s.mu.Lock()
+	s.lastActivity = time.Now()
+	s.mu.Unlock()
+}
+
+// IdleFor 距离上次用户操作的时间，锁定时为 0
+func (s *VaultService) IdleFor() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.keys == nil {
+		return 0
+	}
+	return time.Since(s.lastActivity)
+}
+
+// VaultTx 在 VaultService 的锁内对密码库的一次访问
+type VaultTx struct {
+	s     *VaultService
+	Vault *Vault // 当前版本，Replace 之后为新版本
+	Keys  KeyProvider
+}
+
+// Touch 把这次访问记为用户操作
+func (tx *VaultTx) Touch() {
+	tx.s.lastActivity = time.Now()
+}
+
+// Replace 换入已经保存好的新版本并通知订阅者，之后 tx.Vault 即为新版本
+func (tx *VaultTx) Replace(op string, vault *Vault) {
+	before := tx.Vault
+	tx.Vault, tx.s.vault = vault, vault
+	tx.s.publish(VaultEvent{Kind: VaultChanged, Op: op, Before: before, After: vault, Keys: tx.Keys})
+}
+
+// SetKeys 修改主密码后换入新的密钥，旧密钥由调用方清除
+func (tx *VaultTx) SetKeys(keys KeyProvider) {
+	tx.Keys, tx.s.keys = keys, keys
+}
+
+// Do 持有锁执行 fn，锁定时返回 ErrVaultLocked。fn 中的修改用 tx.Replace 提交
+func (s *VaultService) Do(fn func(tx *VaultTx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.keys == nil {
+		return ErrVaultLocked
+	}
+	return fn(&VaultTx{s: s, Vault: s.vault, Keys: s.keys})
+}
@@ -3,12 +3,13 @@ package main
 import (
 	"context"
 	"embed"
-	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"quick-clip/internal"
 
 	"github.com/wailsapp/wails/v2"
+	wailslogger "github.com/wailsapp/wails/v2/pkg/logger"
 	"github.com/wailsapp/wails/v2/pkg/options"
 	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
 	"github.com/wailsapp/wails/v2/pkg/options/windows"
@@ -20,20 +21,33 @@ var assets embed.FS
 func main() {
 	action := internal.NewAction()
 	configManager := internal.NewConfigManager()
-	config, _ := configManager.Load()
-	fmt.Println(config)
-	app := NewApp(action, configManager, config)
-	trayMgr := internal.NewTrayManager(action, app)
+	config, configErr := configManager.Load()
+	logger, err := internal.NewLogger(internal.DefaultLogDir(), config.Log)
+	if err != nil {
+		logger.Warn("无法打开日志文件，只输出到控制台", "err", err)
+	}
+	defer logger.Close()
+	slog.SetDefault(logger.Logger)
+	if configErr != nil {
+		slog.Warn("读取配置失败", "path", configManager.Path, "err", configErr)
+	}
+	slog.Info("启动", "config", configManager.Path, "logLevel", config.Log.Level)
+	app := NewApp(action, configManager, config, logger)
+	trayMgr := internal.NewTrayManager(action, app, app.vaults)
 	appService := internal.NewAppService()
 
 	configDir, _ := os.UserConfigDir()
 	appRoot := filepath.Join(configDir, "quick-clip")
 
 	// Create application with options
-	err := wails.Run(&options.App{
+	err = wails.Run(&options.App{
 		Title:  "quick-clip",
 		Width:  256,
 		Height: 384,
+		// Wails 的 Trace 日志包含调用参数和返回的明文，只转发 Info 及以上的级别
+		Logger:             logger.Wails(),
+		LogLevel:           wailslogger.INFO,
+		LogLevelProduction: wailslogger.INFO,
 		AssetServer: &assetserver.Options{
 			Assets: assets,
 		},
@@ -72,6 +86,6 @@ func main() {
 	})
 
 	if err != nil {
-		println("Error:", err.Error())
+		slog.Error("启动失败", "err", err)
 	}
 }